@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// meteoAlarm names the MeteoAlarm feeds for baseURL overrides.
+const meteoAlarm = "meteoalarm"
+
+// Alert is an active weather warning. Severity and Urgency use the CAP
+// vocabulary most agencies publish in: Extreme, Severe, Moderate, Minor and
+// Immediate, Expected, Future, Past.
+type Alert struct {
+	Headline    string     `json:"headline"`
+	Event       string     `json:"event,omitempty"`
+	Severity    string     `json:"severity,omitempty"`
+	Urgency     string     `json:"urgency,omitempty"`
+	Area        string     `json:"area,omitempty"`
+	Onset       *time.Time `json:"onset,omitempty"`
+	Expires     *time.Time `json:"expires,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Instruction string     `json:"instruction,omitempty"`
+	Source      string     `json:"source"`
+}
+
+// severityRank orders CAP severities, most severe first.
+var severityRank = map[string]int{"extreme": 0, "severe": 1, "moderate": 2, "minor": 3}
+
+func alertRank(a Alert) int {
+	if r, ok := severityRank[strings.ToLower(a.Severity)]; ok {
+		return r
+	}
+	return len(severityRank)
+}
+
+// activeAlerts drops expired and duplicate alerts and sorts the rest, most
+// severe first.
+func activeAlerts(alerts []Alert, now time.Time) []Alert {
+	seen := map[string]bool{}
+	var out []Alert
+	for _, a := range alerts {
+		if a.Expires != nil && a.Expires.Before(now) {
+			continue
+		}
+		key := strings.ToLower(a.Headline + "\n" + a.Area)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, a)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return alertRank(out[i]) < alertRank(out[j]) })
+	return out
+}
+
+// parseAlertTime reads an RFC 3339 timestamp; anything else is nil.
+func parseAlertTime(s string) *time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+type meteoAlarmFeed struct {
+	Warnings []struct {
+		Alert struct {
+			MsgType string `json:"msgType"`
+			Info    []struct {
+				Language    string `json:"language"`
+				Event       string `json:"event"`
+				Severity    string `json:"severity"`
+				Urgency     string `json:"urgency"`
+				Headline    string `json:"headline"`
+				Description string `json:"description"`
+				Instruction string `json:"instruction"`
+				Onset       string `json:"onset"`
+				Expires     string `json:"expires"`
+				Area        []struct {
+					AreaDesc string `json:"areaDesc"`
+				} `json:"area"`
+			} `json:"info"`
+		} `json:"alert"`
+	} `json:"warnings"`
+}
+
+// fetchMeteoAlarm reads the MeteoAlarm feed of config.MeteoAlarm (a country
+// such as "germany") and keeps the warnings whose area names contain
+// config.MeteoAlarmArea, or the city when that is unset.
+func fetchMeteoAlarm(ctx context.Context, config Config) ([]Alert, error) {
+	u := baseURL(config, meteoAlarm) + "/feeds-" + url.PathEscape(strings.ToLower(config.MeteoAlarm))
+	var feed meteoAlarmFeed
+	if err := getJSON(ctx, u, config, checkOK, &feed); err != nil {
+		return nil, fmt.Errorf("meteoalarm: %w", err)
+	}
+	area := strings.ToLower(config.MeteoAlarmArea)
+	if area == "" {
+		area = strings.ToLower(config.City)
+	}
+
+	var alerts []Alert
+	for _, w := range feed.Warnings {
+		if strings.EqualFold(w.Alert.MsgType, "Cancel") || len(w.Alert.Info) == 0 {
+			continue
+		}
+		// Feeds carry one info block per language; prefer English.
+		info := w.Alert.Info[0]
+		for _, in := range w.Alert.Info {
+			if strings.HasPrefix(strings.ToLower(in.Language), "en") {
+				info = in
+				break
+			}
+		}
+		var areas []string
+		match := false
+		for _, a := range info.Area {
+			areas = append(areas, a.AreaDesc)
+			if strings.Contains(strings.ToLower(a.AreaDesc), area) {
+				match = true
+			}
+		}
+		if !match {
+			continue
+		}
+		headline := info.Headline
+		if headline == "" {
+			headline = info.Event
+		}
+		alerts = append(alerts, Alert{
+			Headline:    headline,
+			Event:       info.Event,
+			Severity:    info.Severity,
+			Urgency:     info.Urgency,
+			Area:        strings.Join(areas, "; "),
+			Onset:       parseAlertTime(info.Onset),
+			Expires:     parseAlertTime(info.Expires),
+			Description: info.Description,
+			Instruction: info.Instruction,
+			Source:      meteoAlarm,
+		})
+	}
+	return alerts, nil
+}
+
+// addMeteoAlarm merges MeteoAlarm warnings into info when a country is
+// configured. A failing feed only costs the warnings, not the report,
+// unless the alerts are all that was asked for.
+func addMeteoAlarm(ctx context.Context, config Config, info *WeatherInfo) error {
+	if config.NoAlerts || config.MeteoAlarm == "" {
+		return nil
+	}
+	alerts, err := fetchMeteoAlarm(ctx, config)
+	if err != nil {
+		if config.AlertsOnly {
+			return err
+		}
+		if !config.Quiet {
+			fmt.Fprintln(os.Stderr, "wrep:", err)
+		}
+		info.AlertsFailed = true
+		return nil
+	}
+	info.Alerts = activeAlerts(append(info.Alerts, alerts...), time.Now())
+	return nil
+}
+
+// renderAlertBanner prints one line per alert above the report.
+func renderAlertBanner(w io.Writer, alerts []Alert, config Config) {
+	color := useColor(config)
+	for _, a := range alerts {
+		line := alertSummary(a)
+		if config.Fancy {
+			line = "⚠️  " + line
+		}
+		if color {
+			line = Bold + alertColor(a) + line + Reset
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// alertSummary is the one-line form of a, e.g.
+// "SEVERE: Heat warning until Fri 20:00".
+func alertSummary(a Alert) string {
+	s := a.Headline
+	if a.Severity != "" {
+		s = strings.ToUpper(a.Severity) + ": " + s
+	}
+	if a.Expires != nil {
+		s += " until " + a.Expires.Format("Mon 15:04")
+	}
+	return s
+}
+
+func alertColor(a Alert) string {
+	switch alertRank(a) {
+	case 0, 1:
+		return Red
+	case 2:
+		return Yellow
+	default:
+		return Cyan
+	}
+}
+
+// alertsSupported reports whether info could carry alerts: MeteoAlarm is
+// configured or a provider behind the report has an alerts source.
+func alertsSupported(info WeatherInfo, config Config) bool {
+	if config.MeteoAlarm != "" {
+		return true
+	}
+	names := []string{info.Provider}
+	if info.Consensus != nil {
+		names = info.Consensus.Providers
+	}
+	for _, name := range names {
+		if p, ok := LookupProvider(name); ok && p.Capabilities().Alerts {
+			return true
+		}
+	}
+	return false
+}
+
+// renderAlertsOnly is the -alerts-only output: every alert in full. A
+// report from a provider without alerts is an error rather than an empty
+// list, which would read as an all-clear.
+func renderAlertsOnly(w io.Writer, info WeatherInfo, config Config) error {
+	if !alertsSupported(info, config) {
+		name := info.Provider
+		if info.Consensus != nil {
+			name = strings.Join(info.Consensus.Providers, ", ")
+		}
+		return fmt.Errorf("%s does not report alerts; use weatherapi or nws, or set meteoalarm in ~/.wrep", name)
+	}
+	// Old alerts may have been lifted or joined by new ones.
+	if info.Stale != nil {
+		return fmt.Errorf("alerts unavailable: %s", info.Stale.Error)
+	}
+	if config.JSON {
+		alerts := info.Alerts
+		if alerts == nil {
+			alerts = []Alert{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(alerts)
+	}
+	if len(info.Alerts) == 0 {
+		fmt.Fprintf(w, "No active alerts for %s\n", config.City)
+		return nil
+	}
+	for i, a := range info.Alerts {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		renderAlertBanner(w, []Alert{a}, config)
+		if a.Area != "" {
+			fmt.Fprintf(w, "  Area:    %s\n", a.Area)
+		}
+		if a.Onset != nil || a.Expires != nil {
+			fmt.Fprintf(w, "  When:    %s – %s\n", formatAlertTime(a.Onset), formatAlertTime(a.Expires))
+		}
+		if a.Urgency != "" {
+			fmt.Fprintf(w, "  Urgency: %s\n", a.Urgency)
+		}
+		fmt.Fprintf(w, "  Source:  %s\n", a.Source)
+		for _, text := range []string{a.Description, a.Instruction} {
+			if text = strings.TrimSpace(text); text != "" {
+				fmt.Fprintln(w)
+				for _, line := range strings.Split(text, "\n") {
+					fmt.Fprintln(w, "  "+strings.TrimSpace(line))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func formatAlertTime(t *time.Time) string {
+	if t == nil {
+		return "?"
+	}
+	return t.Format("Mon Jan 2 15:04")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActiveAlerts(t *testing.T) {
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	alerts := []Alert{
+		{Headline: "Fog advisory", Severity: "Minor", Area: "Coast"},
+		{Headline: "Old heat warning", Severity: "Extreme", Expires: &past},
+		{Headline: "Wind warning", Severity: "Moderate", Area: "Coast", Expires: &future},
+		{Headline: "Special statement"},
+		{Headline: "Tornado warning", Severity: "EXTREME", Area: "Inland"},
+		{Headline: "wind warning", Severity: "Severe", Area: "coast", Source: "meteoalarm"},
+		{Headline: "Wind warning", Severity: "Severe", Area: "Hills"},
+	}
+	got := activeAlerts(alerts, now)
+	want := []string{"Tornado warning/Inland", "Wind warning/Hills", "Wind warning/Coast", "Fog advisory/Coast", "Special statement/"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d alerts, want %d: %+v", len(got), len(want), got)
+	}
+	for i, a := range got {
+		if a.Headline+"/"+a.Area != want[i] {
+			t.Errorf("alert %d: %s/%s, want %s", i, a.Headline, a.Area, want[i])
+		}
+	}
+	if activeAlerts(nil, now) != nil {
+		t.Error("no alerts should stay nil")
+	}
+}
+
+func TestParseAlertTime(t *testing.T) {
+	if got := parseAlertTime(" 2024-05-18T20:00:00+02:00 "); got == nil || !got.Equal(time.Date(2024, 5, 18, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseAlertTime = %v, want 18:00 UTC", got)
+	}
+	for _, s := range []string{"", "tomorrow", "2024-05-18 20:00"} {
+		if got := parseAlertTime(s); got != nil {
+			t.Errorf("parseAlertTime(%q) = %v, want nil", s, got)
+		}
+	}
+}
+
+func TestAlertSummary(t *testing.T) {
+	expires := time.Date(2024, 5, 17, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		alert Alert
+		want  string
+	}{
+		{Alert{Headline: "Heat warning", Severity: "Severe", Expires: &expires}, "SEVERE: Heat warning until Fri 20:00"},
+		{Alert{Headline: "Heat warning", Severity: "Minor"}, "MINOR: Heat warning"},
+		{Alert{Headline: "Special statement"}, "Special statement"},
+	}
+	for _, tt := range tests {
+		if got := alertSummary(tt.alert); got != tt.want {
+			t.Errorf("alertSummary(%+v) = %q, want %q", tt.alert, got, tt.want)
+		}
+	}
+}
+
+func TestRenderAlertsOnly(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderAlertsOnly(&buf, WeatherInfo{Provider: ProviderOpenMeteo}, Config{City: "Berlin"}); err == nil {
+		t.Error("provider without alerts: want error")
+	}
+
+	buf.Reset()
+	if err := renderAlertsOnly(&buf, WeatherInfo{Provider: ProviderNWS}, Config{City: "Miami"}); err != nil || buf.String() != "No active alerts for Miami\n" {
+		t.Errorf("no alerts: %q, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := renderAlertsOnly(&buf, WeatherInfo{Provider: ProviderNWS}, Config{City: "Miami", JSON: true}); err != nil || strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("no alerts as JSON: %q, %v", buf.String(), err)
+	}
+
+	// MeteoAlarm makes any provider a source of alerts.
+	buf.Reset()
+	if err := renderAlertsOnly(&buf, WeatherInfo{Provider: ProviderOpenMeteo}, Config{City: "Berlin", MeteoAlarm: "germany"}); err != nil {
+		t.Errorf("with meteoalarm: %v", err)
+	}
+
+	stale := WeatherInfo{Provider: ProviderNWS, Stale: &Staleness{Error: "timeout"}}
+	if err := renderAlertsOnly(&buf, stale, Config{City: "Miami"}); err == nil {
+		t.Error("stale report: want error")
+	}
+}
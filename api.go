@@ -1,12 +1,11 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -30,257 +29,293 @@ const (
 	Snowy
 	Stormy
 	Foggy
+	ClearNight
 )
 
 type WeatherInfo struct {
-	TempC       float64       `json:"temp_c"`
-	TempF       float64       `json:"temp_f"`
-	UVIndex     float64       `json:"uv_index"`
-	Description string        `json:"description"`
-	Type        WeatherType   `json:"-"`
-	Forecast    []ForecastDay `json:"forecast,omitempty"`
+	TempC       float64             `json:"temp_c"`
+	TempF       float64             `json:"temp_f"`
+	UVIndex     float64             `json:"uv_index"`
+	Description string              `json:"description"`
+	Type        WeatherType         `json:"-"`
+	Forecast    []ForecastDay       `json:"forecast,omitempty"`
+	Hourly      []ForecastHour      `json:"hourly,omitempty"`
+	Provider    string              `json:"provider,omitempty"`
+	Consensus   *Consensus          `json:"consensus,omitempty"`
+	Aviation    *Aviation           `json:"aviation,omitempty"`
+	Station     *StationObservation `json:"station,omitempty"`
+	Stale       *Staleness          `json:"stale,omitempty"`
+	Details     *Details            `json:"details,omitempty"`
+	Alerts      []Alert             `json:"alerts,omitempty"`
+	// AlertsFailed is set when an alert source could not be read, so
+	// Alerts may be missing warnings. Such reports are not cached.
+	AlertsFailed bool `json:"-"`
 }
 
+// Details are the current conditions beyond temperature and UV. Providers
+// that don't report them leave WeatherInfo.Details nil. Like temperatures,
+// each measurement is kept in both metric and imperial units.
+type Details struct {
+	FeelsLikeC      float64 `json:"feels_like_c"`
+	FeelsLikeF      float64 `json:"feels_like_f"`
+	WindKph         float64 `json:"wind_kph"`
+	WindMph         float64 `json:"wind_mph"`
+	GustKph         float64 `json:"gust_kph,omitempty"`
+	GustMph         float64 `json:"gust_mph,omitempty"`
+	WindDirDeg      int     `json:"wind_dir_deg"`
+	WindDir         string  `json:"wind_dir"`
+	Humidity        int     `json:"humidity"`
+	PressureMb      float64 `json:"pressure_mb"`
+	PressureIn      float64 `json:"pressure_in"`
+	VisibilityKm    float64 `json:"visibility_km"`
+	VisibilityMiles float64 `json:"visibility_miles"`
+	CloudCover      int     `json:"cloud_cover"`
+	PrecipMM        float64 `json:"precip_mm"`
+	PrecipIn        float64 `json:"precip_in"`
+}
+
+// Consensus describes how a merged report was built: which providers
+// contributed and how far apart their current readings were.
+type Consensus struct {
+	Providers   []string          `json:"providers"`
+	Failed      map[string]string `json:"failed,omitempty"`
+	TempSpreadC float64           `json:"temp_spread_c"`
+	TempSpreadF float64           `json:"temp_spread_f"`
+	UVSpread    float64           `json:"uv_spread"`
+	Agreement   float64           `json:"type_agreement"`
+}
+
+// Spread is the max-min range of each field across providers for one
+// forecast day. Agreement is the share of providers that reported the
+// majority WeatherType.
+type Spread struct {
+	Sources   int     `json:"sources"`
+	MinTempC  float64 `json:"min_temp_c"`
+	MaxTempC  float64 `json:"max_temp_c"`
+	MinTempF  float64 `json:"min_temp_f"`
+	MaxTempF  float64 `json:"max_temp_f"`
+	Agreement float64 `json:"type_agreement"`
+}
+
+// ForecastDay is one day of a forecast. NoMin and NoMax mark a low or high
+// the source did not give, such as a TAF without TX/TN groups; those
+// temperatures are left out of JSON.
 type ForecastDay struct {
 	Date        time.Time   `json:"date"`
 	MinTempC    float64     `json:"min_temp_c"`
 	MaxTempC    float64     `json:"max_temp_c"`
 	MinTempF    float64     `json:"min_temp_f"`
 	MaxTempF    float64     `json:"max_temp_f"`
+	NoMin       bool        `json:"-"`
+	NoMax       bool        `json:"-"`
 	Description string      `json:"description"`
 	Type        WeatherType `json:"-"`
+	Spread      *Spread     `json:"spread,omitempty"`
+	Details     *DayDetails `json:"details,omitempty"`
 }
 
-var (
-	UserAgent  = "wrep"
-	httpClient = &http.Client{Timeout: 30 * time.Second}
-)
+// forecastDayJSON is the JSON form of ForecastDay, with pointers so that
+// missing temperatures can be omitted and read back as missing.
+type forecastDayJSON struct {
+	Date        time.Time   `json:"date"`
+	MinTempC    *float64    `json:"min_temp_c,omitempty"`
+	MaxTempC    *float64    `json:"max_temp_c,omitempty"`
+	MinTempF    *float64    `json:"min_temp_f,omitempty"`
+	MaxTempF    *float64    `json:"max_temp_f,omitempty"`
+	Description string      `json:"description"`
+	Spread      *Spread     `json:"spread,omitempty"`
+	Details     *DayDetails `json:"details,omitempty"`
+}
 
-type wttrInDesc struct {
-	Value string `json:"value"`
+func (d ForecastDay) MarshalJSON() ([]byte, error) {
+	j := forecastDayJSON{Date: d.Date, Description: d.Description, Spread: d.Spread, Details: d.Details}
+	if !d.NoMin {
+		j.MinTempC, j.MinTempF = &d.MinTempC, &d.MinTempF
+	}
+	if !d.NoMax {
+		j.MaxTempC, j.MaxTempF = &d.MaxTempC, &d.MaxTempF
+	}
+	return json.Marshal(j)
 }
 
-type wttrInResponse struct {
-	CurrentCondition []struct {
-		TempC       string       `json:"temp_C"`
-		TempF       string       `json:"temp_F"`
-		UvIndex     string       `json:"uvIndex"`
-		WeatherDesc []wttrInDesc `json:"weatherDesc"`
-	} `json:"current_condition"`
-	Weather []struct {
-		Date     string `json:"date"`
-		MaxTempC string `json:"maxtempC"`
-		MaxTempF string `json:"maxtempF"`
-		MinTempC string `json:"mintempC"`
-		MinTempF string `json:"mintempF"`
-		Hourly   []struct {
-			WeatherDesc []wttrInDesc `json:"weatherDesc"`
-		} `json:"hourly"`
-	} `json:"weather"`
+func (d *ForecastDay) UnmarshalJSON(b []byte) error {
+	var j forecastDayJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*d = ForecastDay{Date: j.Date, Description: j.Description, Spread: j.Spread, Details: j.Details}
+	d.NoMin, d.NoMax = j.MinTempC == nil, j.MaxTempC == nil
+	if j.MinTempC != nil {
+		d.MinTempC = *j.MinTempC
+		d.MinTempF = celsiusToFahrenheit(d.MinTempC)
+	}
+	if j.MinTempF != nil {
+		d.MinTempF = *j.MinTempF
+	}
+	if j.MaxTempC != nil {
+		d.MaxTempC = *j.MaxTempC
+		d.MaxTempF = celsiusToFahrenheit(d.MaxTempC)
+	}
+	if j.MaxTempF != nil {
+		d.MaxTempF = *j.MaxTempF
+	}
+	return nil
 }
 
-type weatherAPIResponse struct {
-	Current struct {
-		TempC     float64 `json:"temp_c"`
-		TempF     float64 `json:"temp_f"`
-		UVIndex   float64 `json:"uv"`
-		Condition struct {
-			Text string `json:"text"`
-		} `json:"condition"`
-	} `json:"current"`
-	Forecast struct {
-		ForecastDay []struct {
-			Date string `json:"date"`
-			Day  struct {
-				MaxTempC  float64 `json:"maxtemp_c"`
-				MaxTempF  float64 `json:"maxtemp_f"`
-				MinTempC  float64 `json:"mintemp_c"`
-				MinTempF  float64 `json:"mintemp_f"`
-				Condition struct {
-					Text string `json:"text"`
-				} `json:"condition"`
-			} `json:"day"`
-		} `json:"forecastday"`
-	} `json:"forecast"`
+// ForecastHour is one slot of an hourly forecast: a single hour, or three
+// for wttr.in. Time is the wall-clock time at the location; its zone
+// carries no meaning.
+type ForecastHour struct {
+	Time         time.Time   `json:"time"`
+	TempC        float64     `json:"temp_c"`
+	TempF        float64     `json:"temp_f"`
+	Description  string      `json:"description"`
+	Type         WeatherType `json:"-"`
+	ChanceOfRain int         `json:"chance_of_rain"`
+	ChanceOfSnow int         `json:"chance_of_snow"`
+	WindKph      float64     `json:"wind_kph"`
+	WindMph      float64     `json:"wind_mph"`
+	WindDir      string      `json:"wind_dir"`
 }
 
-func FetchWeather(config Config) (WeatherInfo, error) {
-	urlStr, err := buildURL(config)
-	if err != nil {
-		return WeatherInfo{}, err
-	}
+// inHourlyWindow reports whether a slot starting at t falls within the next
+// hours hours, counted from the start of the slot now is in.
+func inHourlyWindow(t time.Time, slot time.Duration, now time.Time, hours int) bool {
+	start := now.Truncate(slot)
+	return !t.Before(start) && t.Before(start.Add(time.Duration(hours)*time.Hour))
+}
 
-	if config.Verbose && !config.Quiet {
-		fmt.Fprintln(os.Stderr, "Requesting:", urlStr)
+// DayDetails are the extras some providers report per forecast day.
+// Chances are percentages; Sunrise, Sunset and Moonrise are local "15:04"
+// times, empty when there is none that day.
+type DayDetails struct {
+	ChanceOfRain int     `json:"chance_of_rain"`
+	ChanceOfSnow int     `json:"chance_of_snow"`
+	PrecipMM     float64 `json:"precip_mm"`
+	PrecipIn     float64 `json:"precip_in"`
+	MaxWindKph   float64 `json:"max_wind_kph"`
+	MaxWindMph   float64 `json:"max_wind_mph"`
+	AvgHumidity  int     `json:"avg_humidity"`
+	UVIndex      float64 `json:"uv_index"`
+	Sunrise      string  `json:"sunrise,omitempty"`
+	Sunset       string  `json:"sunset,omitempty"`
+	Moonrise     string  `json:"moonrise,omitempty"`
+}
+
+var (
+	UserAgent  = "wrep"
+	httpClient = &http.Client{Timeout: defaultTimeout}
+)
+
+// fetchFrom runs a single provider. config.APIProvider must name p.
+func fetchFrom(ctx context.Context, p Provider, config Config) (WeatherInfo, error) {
+	config = limitToCapabilities(config, p.Capabilities())
+	if !p.Capabilities().Local {
+		if err := providerLimiter.wait(ctx, p.Name(), rateLimitFor(config, p.Name())); err != nil {
+			return WeatherInfo{}, err
+		}
+	}
+	if f, ok := p.(Fetcher); ok {
+		return f.Fetch(ctx, config)
 	}
 
-	req, err := http.NewRequest(http.MethodGet, urlStr, nil)
+	req, err := p.BuildRequest(ctx, config)
 	if err != nil {
 		return WeatherInfo{}, fmt.Errorf("failed to build request: %w", err)
 	}
-	req.Header.Set("User-Agent", UserAgent)
-
-	resp, err := httpClient.Do(req)
+	body, err := fetchBody(req, config, p.CheckStatus)
 	if err != nil {
-		return WeatherInfo{}, fmt.Errorf("HTTP request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if err := checkStatus(resp, config.APIProvider); err != nil {
 		return WeatherInfo{}, err
 	}
+	return p.Parse(body, config)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return WeatherInfo{}, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	switch config.APIProvider {
-	case ProviderWeatherAPI:
-		return parseWeatherAPI(body, config)
-	default:
-		return parseWttr(body, config)
+// limitToCapabilities trims the forecast and hourly lengths of config to
+// what caps can deliver, so each provider of a chain asks for no more than
+// it has.
+func limitToCapabilities(config Config, caps Capabilities) Config {
+	if caps.MaxForecastDays > 0 {
+		config.Forecast = min(config.Forecast, caps.MaxForecastDays)
 	}
+	config.Hourly = min(config.Hourly, caps.MaxHourlyHours)
+	return config
 }
 
-func buildURL(config Config) (string, error) {
-	switch config.APIProvider {
-	case ProviderWeatherAPI:
-		base := "https://api.weatherapi.com/v1/current.json"
-		if config.Forecast > 0 {
-			base = "https://api.weatherapi.com/v1/forecast.json"
-		}
-		u, err := url.Parse(base)
-		if err != nil {
-			return "", fmt.Errorf("failed to parse base URL: %w", err)
+// warnCapabilities tells the user which providers of the chain will return
+// less than config asks for.
+func warnCapabilities(w io.Writer, config Config) {
+	chain := config.APIProviders
+	if len(chain) == 0 {
+		chain = []string{config.APIProvider}
+	}
+	for _, name := range chain {
+		p, ok := LookupProvider(name)
+		if !ok {
+			continue
 		}
-		q := u.Query()
-		q.Set("key", config.APIKey)
-		q.Set("q", config.City)
-		if config.Forecast > 0 {
-			q.Set("days", strconv.Itoa(config.Forecast))
+		caps := p.Capabilities()
+		if caps.MaxForecastDays > 0 && config.Forecast > caps.MaxForecastDays {
+			fmt.Fprintf(w, "wrep: %s returns at most %d days; truncating\n", name, caps.MaxForecastDays)
 		}
-		u.RawQuery = q.Encode()
-		return u.String(), nil
-	default:
-		u, err := url.Parse("https://wttr.in/" + url.PathEscape(config.City))
-		if err != nil {
-			return "", fmt.Errorf("failed to parse wttr.in URL: %w", err)
+		switch {
+		case config.Hourly > 0 && caps.MaxHourlyHours == 0:
+			fmt.Fprintf(w, "wrep: %s has no hourly forecast\n", name)
+		case config.Hourly > caps.MaxHourlyHours:
+			fmt.Fprintf(w, "wrep: %s returns at most %d hours; truncating\n", name, caps.MaxHourlyHours)
 		}
-		q := u.Query()
-		q.Set("format", "j1")
-		u.RawQuery = q.Encode()
-		return u.String(), nil
 	}
 }
 
-func checkStatus(resp *http.Response, provider string) error {
-	if provider == ProviderWeatherAPI {
-		switch resp.StatusCode {
-		case http.StatusOK:
-			return nil
-		case http.StatusUnauthorized:
-			return errors.New("unauthorized: invalid or missing API key")
-		case http.StatusBadRequest:
-			return errors.New("bad request: city not provided or invalid")
-		case http.StatusForbidden:
-			return errors.New("forbidden: API access denied or quota exceeded")
-		default:
-			return fmt.Errorf("unexpected HTTP status: %d %s", resp.StatusCode, resp.Status)
+// fetchBody performs req, classifies the response with check and returns the
+// body. A previous response that is still fresh per Cache-Control or Expires
+// is reused without touching the network; stale ones are revalidated with
+// their ETag and Last-Modified, and a 304 reuses the previous body.
+func fetchBody(req *http.Request, config Config, check func(*http.Response) error) ([]byte, error) {
+	key := req.URL.String()
+	cached, haveCached := lookupConditional(key)
+	if haveCached && time.Now().Before(cached.expires) {
+		if config.Verbose && !config.Quiet {
+			fmt.Fprintln(os.Stderr, "Reusing response until", cached.expires.Local().Format(time.RFC3339)+":", req.URL)
 		}
+		return cached.body, nil
 	}
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected HTTP status: %d %s", resp.StatusCode, resp.Status)
+	if haveCached {
+		cached.setValidators(req)
 	}
-	return nil
-}
 
-func parseWttr(body []byte, config Config) (WeatherInfo, error) {
-	var r wttrInResponse
-	if err := json.Unmarshal(body, &r); err != nil {
-		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
-	}
-	if len(r.CurrentCondition) == 0 {
-		return WeatherInfo{}, errors.New("no current condition data in response")
-	}
-	cc := r.CurrentCondition[0]
-	if len(cc.WeatherDesc) == 0 {
-		return WeatherInfo{}, errors.New("no weather description in response")
+	if config.Verbose && !config.Quiet {
+		fmt.Fprintln(os.Stderr, "Requesting:", req.URL)
 	}
 
-	info := WeatherInfo{
-		Description: strings.TrimSpace(cc.WeatherDesc[0].Value),
-		TempC:       parseFloat(cc.TempC),
-		TempF:       parseFloat(cc.TempF),
-		UVIndex:     parseFloat(cc.UvIndex),
+	resp, err := doWithRetry(req, config)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
-	info.Type = ClassifyWeather(info.Description)
+	defer resp.Body.Close()
 
-	if config.Forecast > 0 {
-		for _, day := range r.Weather {
-			desc := representativeWttrDesc(day.Hourly)
-			d, _ := time.Parse("2006-01-02", day.Date)
-			info.Forecast = append(info.Forecast, ForecastDay{
-				Date:        d,
-				MaxTempC:    parseFloat(day.MaxTempC),
-				MinTempC:    parseFloat(day.MinTempC),
-				MaxTempF:    parseFloat(day.MaxTempF),
-				MinTempF:    parseFloat(day.MinTempF),
-				Description: desc,
-				Type:        ClassifyWeather(desc),
-			})
+	if haveCached && resp.StatusCode == http.StatusNotModified {
+		if config.Verbose && !config.Quiet {
+			fmt.Fprintln(os.Stderr, "Not modified:", req.URL)
 		}
+		storeConditional(key, resp, cached.body)
+		return cached.body, nil
 	}
-	return info, nil
-}
 
-func parseWeatherAPI(body []byte, config Config) (WeatherInfo, error) {
-	var r weatherAPIResponse
-	if err := json.Unmarshal(body, &r); err != nil {
-		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
-	}
-	info := WeatherInfo{
-		Description: strings.TrimSpace(r.Current.Condition.Text),
-		TempC:       r.Current.TempC,
-		TempF:       r.Current.TempF,
-		UVIndex:     r.Current.UVIndex,
+	if err := check(resp); err != nil {
+		return nil, err
 	}
-	info.Type = ClassifyWeather(info.Description)
-	if config.Forecast > 0 {
-		for _, day := range r.Forecast.ForecastDay {
-			d, _ := time.Parse("2006-01-02", day.Date)
-			desc := strings.TrimSpace(day.Day.Condition.Text)
-			info.Forecast = append(info.Forecast, ForecastDay{
-				Date:        d,
-				MaxTempC:    day.Day.MaxTempC,
-				MinTempC:    day.Day.MinTempC,
-				MaxTempF:    day.Day.MaxTempF,
-				MinTempF:    day.Day.MinTempF,
-				Description: desc,
-				Type:        ClassifyWeather(desc),
-			})
-		}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
-	return info, nil
+
+	storeConditional(key, resp, body)
+	return body, nil
 }
 
-// representativeWttrDesc picks the noon entry (3-hour interval, index 4) from
-// wttr.in's hourly slice so the forecast row shows a midday condition rather
-// than midnight. Falls back to whatever's available.
-func representativeWttrDesc(hourly []struct {
-	WeatherDesc []wttrInDesc `json:"weatherDesc"`
-}) string {
-	if len(hourly) == 0 {
-		return ""
-	}
-	idx := 4
-	if idx >= len(hourly) {
-		idx = 0
-	}
-	if len(hourly[idx].WeatherDesc) == 0 {
-		return ""
-	}
-	return strings.TrimSpace(hourly[idx].WeatherDesc[0].Value)
+func unexpectedStatus(resp *http.Response) error {
+	return fmt.Errorf("unexpected HTTP status: %d %s", resp.StatusCode, resp.Status)
 }
 
 func parseFloat(s string) float64 {
@@ -288,6 +323,16 @@ func parseFloat(s string) float64 {
 	return f
 }
 
+// parseClock turns a provider's "06:12 AM" into "06:12". Placeholders such
+// as "No moonrise" yield "".
+func parseClock(s string) string {
+	t, err := time.Parse("03:04 PM", strings.TrimSpace(s))
+	if err != nil {
+		return ""
+	}
+	return t.Format("15:04")
+}
+
 func ClassifyWeather(desc string) WeatherType {
 	desc = strings.ToLower(desc)
 	switch {
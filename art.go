@@ -48,6 +48,13 @@ var weatherArtTable = map[WeatherType][]string{
 		`  _ - _ - _  `,
 		`   - _ - _ - `,
 	},
+	ClearNight: {
+		`     ,-.     `,
+		`    /  /     `,
+		`   |  |   *  `,
+		`    \  \     `,
+		`     '-'   * `,
+	},
 	Unknown: {
 		`    .---.    `,
 		`    |   |    `,
@@ -83,6 +90,13 @@ func renderArt(w io.Writer, info WeatherInfo, config Config) {
 		{"Temperature", formatTemp(info.TempC, info.TempF, config.Unit)},
 		{"UV Index", formatUV(info.UVIndex)},
 	}
+	if c := info.Consensus; c != nil {
+		infoLines = append(infoLines, struct{ label, value string }{
+			"Providers", fmt.Sprintf("%d (spread %s)", len(c.Providers), formatTemp(c.TempSpreadC, c.TempSpreadF, config.Unit)),
+		})
+	}
+
+	infoLines = append(infoLines, detailLines(info.Details, config.Unit)...)
 
 	art := WeatherArt(info.Type)
 
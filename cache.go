@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Staleness marks a report served from the response cache because every
+// provider failed.
+type Staleness struct {
+	FetchedAt  time.Time `json:"fetched_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	Error      string    `json:"error"`
+}
+
+// cacheEntry is a cached report on disk. WeatherType is not part of the
+// JSON form of WeatherInfo, so the types are stored alongside.
+type cacheEntry struct {
+	Fetched       time.Time     `json:"fetched"`
+	Info          WeatherInfo   `json:"info"`
+	Type          WeatherType   `json:"type"`
+	ForecastTypes []WeatherType `json:"forecast_types,omitempty"`
+	HourlyTypes   []WeatherType `json:"hourly_types,omitempty"`
+}
+
+// responseCacheKey identifies a report by everything that changes it. It
+// returns false when the report must not be cached: with -no-cache, while
+// recording or replaying, for -alerts-only, and for providers that read
+// local input.
+func responseCacheKey(config Config) (string, bool) {
+	if config.NoCache || config.RecordDir != "" || config.ReplayDir != "" || config.MetarFile != "" {
+		return "", false
+	}
+	// -alerts-only asks what is in effect now; a cached report could hide
+	// a new warning or one that was lifted.
+	if config.AlertsOnly {
+		return "", false
+	}
+	chain := config.APIProviders
+	if len(chain) == 0 {
+		chain = []string{config.APIProvider}
+	}
+	for _, name := range chain {
+		if p, ok := LookupProvider(name); ok && p.Capabilities().Local {
+			return "", false
+		}
+	}
+	parts := []string{strings.Join(chain, ","), strings.ToLower(config.City), config.Unit, strconv.Itoa(config.Forecast)}
+	if config.Hourly > 0 {
+		parts = append(parts, "hourly="+strconv.Itoa(config.Hourly))
+	}
+	// Endpoints and custom mappings change what a provider answers.
+	parts = append(parts, mapPairs("baseURL.", config.BaseURLs)...)
+	parts = append(parts, mapPairs("custom.", config.Custom)...)
+	if config.NoAlerts {
+		parts = append(parts, "no-alerts")
+	} else if config.MeteoAlarm != "" {
+		parts = append(parts, "meteoalarm="+config.MeteoAlarm+"/"+config.MeteoAlarmArea)
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:16]), true
+}
+
+// mapPairs renders m as sorted "prefix+key=value" lines.
+func mapPairs(prefix string, m map[string]string) []string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, prefix+k+"="+v)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+func responseCachePath(key string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "wrep", "responses", key+".json"), nil
+}
+
+func loadCachedResponse(key string) (cacheEntry, bool) {
+	path, err := responseCachePath(key)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cacheEntry{}, false
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(b, &e); err != nil {
+		return cacheEntry{}, false
+	}
+	e.Info.Type = e.Type
+	// Alerts may have run out since the report was cached.
+	e.Info.Alerts = activeAlerts(e.Info.Alerts, time.Now())
+	for i := range e.Info.Forecast {
+		if i < len(e.ForecastTypes) {
+			e.Info.Forecast[i].Type = e.ForecastTypes[i]
+		}
+	}
+	for i := range e.Info.Hourly {
+		if i < len(e.HourlyTypes) {
+			e.Info.Hourly[i].Type = e.HourlyTypes[i]
+		}
+	}
+	return e, true
+}
+
+// storeCachedResponse writes info atomically so concurrent wrep processes,
+// e.g. several tmux panes, never read a partial entry.
+func storeCachedResponse(key string, info WeatherInfo) error {
+	path, err := responseCachePath(key)
+	if err != nil {
+		return err
+	}
+	e := cacheEntry{Fetched: time.Now().UTC(), Info: info, Type: info.Type}
+	for _, d := range info.Forecast {
+		e.ForecastTypes = append(e.ForecastTypes, d.Type)
+	}
+	for _, h := range info.Hourly {
+		e.HourlyTypes = append(e.HourlyTypes, h.Type)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
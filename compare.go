@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	compareLabelW = 20
+	compareCellW  = 24
+)
+
+// runCompare fetches the city from every configured provider and renders
+// them side by side.
+func runCompare(ctx context.Context, cfg Config, out io.Writer) error {
+	names := pollProviders(cfg)
+	if len(names) == 0 {
+		return errors.New("no providers available to compare")
+	}
+	results := fetchAll(ctx, cfg, names)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ok := 0
+	for _, r := range results {
+		if r.err == nil {
+			ok++
+		} else if !cfg.Quiet {
+			fmt.Fprintf(os.Stderr, "wrep: %s failed: %v\n", r.name, r.err)
+		}
+	}
+	if ok == 0 {
+		return errors.New("all providers failed")
+	}
+
+	if cfg.JSON {
+		renderCompareJSON(out, results)
+		return nil
+	}
+	renderCompare(out, results, cfg)
+	return nil
+}
+
+func renderCompareJSON(w io.Writer, results []providerResult) {
+	byProvider := map[string]any{}
+	for _, r := range results {
+		if r.err != nil {
+			byProvider[r.name] = map[string]string{"error": r.err.Error()}
+			continue
+		}
+		byProvider[r.name] = r.info
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(byProvider)
+}
+
+type compareCell struct {
+	text  string
+	temp  float64
+	valid bool
+}
+
+func renderCompare(w io.Writer, results []providerResult, config Config) {
+	color := useColor(config)
+
+	widths := []int{compareLabelW}
+	headers := []string{padRight(" "+config.City, compareLabelW)}
+	for _, r := range results {
+		widths = append(widths, compareCellW)
+		headers = append(headers, padRight(" "+r.name, compareCellW))
+	}
+	top, mid, bot := tableBorders(widths)
+
+	current := make([]compareCell, len(results))
+	for i, r := range results {
+		if r.err != nil {
+			current[i] = compareCell{text: "error"}
+			continue
+		}
+		current[i] = compareCell{
+			text:  formatTemp(r.info.TempC, r.info.TempF, config.Unit) + " " + r.info.Description,
+			temp:  r.info.TempC,
+			valid: true,
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, top)
+	if color {
+		for i := 1; i < len(headers); i++ {
+			headers[i] = Bold + headers[i] + Reset
+		}
+	}
+	fmt.Fprintln(w, tableRow(headers))
+	fmt.Fprintln(w, mid)
+	fmt.Fprintln(w, compareRow(" Now", current, color))
+
+	if config.Forecast > 0 {
+		dates, byDate := compareDays(results, config.Forecast, config.Unit)
+		for i, k := range dates {
+			label := fmt.Sprintf(" Day %d %s", i+1, formatForecastDate(time.Unix(k, 0).UTC()))
+			fmt.Fprintln(w, compareRow(label, byDate[k], color))
+		}
+	}
+	fmt.Fprintln(w, bot)
+	fmt.Fprintln(w)
+}
+
+// compareDays lines up each provider's forecast by date. The returned map
+// holds one cell per provider, in result order, for every date.
+func compareDays(results []providerResult, limit int, unit string) ([]int64, map[int64][]compareCell) {
+	byDate := map[int64][]compareCell{}
+	var dates []int64
+	for i, r := range results {
+		if r.err != nil {
+			continue
+		}
+		for _, d := range r.info.Forecast {
+			k := d.Date.Unix()
+			if _, ok := byDate[k]; !ok {
+				dates = append(dates, k)
+				byDate[k] = make([]compareCell, len(results))
+			}
+			byDate[k][i] = compareCell{
+				text:  formatTempRange(d, unit) + " " + d.Description,
+				temp:  d.MaxTempC,
+				valid: !d.NoMax,
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i] < dates[j] })
+	if len(dates) > limit {
+		dates = dates[:limit]
+	}
+	return dates, byDate
+}
+
+// compareRow renders one table row. A cell whose temperature is more than
+// spreadWarnC away from the row's median is marked with "!" (and in red
+// when color is on).
+func compareRow(label string, cells []compareCell, color bool) string {
+	var temps []float64
+	for _, c := range cells {
+		if c.valid {
+			temps = append(temps, c.temp)
+		}
+	}
+	mid := median(temps)
+
+	row := []string{padRight(label, compareLabelW)}
+	for _, c := range cells {
+		text := c.text
+		if !c.valid && text == "" {
+			text = "-"
+		}
+		outlier := c.valid && len(temps) > 1 && math.Abs(c.temp-mid) > spreadWarnC
+		marker := ""
+		if outlier {
+			marker = " !"
+		}
+		cell := padRight(" "+truncate(text, compareCellW-1-len(marker))+marker, compareCellW)
+		if outlier && color {
+			cell = Red + cell + Reset
+		}
+		row = append(row, cell)
+	}
+	return tableRow(row)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// conditionalEntry is the last successful response for a URL. While it is
+// fresh it is reused without a request; afterwards its validators are sent
+// so an unchanged resource costs a 304 instead of a full body.
+type conditionalEntry struct {
+	body         []byte
+	etag         string
+	lastModified string
+	expires      time.Time
+}
+
+var (
+	conditionalMu    sync.Mutex
+	conditionalCache = map[string]conditionalEntry{}
+)
+
+func lookupConditional(key string) (conditionalEntry, bool) {
+	conditionalMu.Lock()
+	defer conditionalMu.Unlock()
+	e, ok := conditionalCache[key]
+	return e, ok
+}
+
+// setValidators adds If-None-Match and If-Modified-Since for e to req.
+func (e conditionalEntry) setValidators(req *http.Request) {
+	if e.etag != "" {
+		req.Header.Set("If-None-Match", e.etag)
+	}
+	if e.lastModified != "" {
+		req.Header.Set("If-Modified-Since", e.lastModified)
+	}
+}
+
+// storeConditional records body under key together with the validators and
+// freshness from resp. On a 304 the previous validators are kept where resp
+// omits them. Responses marked no-store are dropped.
+func storeConditional(key string, resp *http.Response, body []byte) {
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	conditionalMu.Lock()
+	defer conditionalMu.Unlock()
+	if _, ok := cc["no-store"]; ok {
+		delete(conditionalCache, key)
+		return
+	}
+	prev := conditionalCache[key]
+	e := conditionalEntry{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		expires:      freshUntil(resp.Header, cc, time.Now()),
+	}
+	if e.etag == "" {
+		e.etag = prev.etag
+	}
+	if e.lastModified == "" {
+		e.lastModified = prev.lastModified
+	}
+	if e.etag == "" && e.lastModified == "" && !e.expires.After(time.Now()) {
+		// Nothing to revalidate with and already stale: not worth keeping.
+		delete(conditionalCache, key)
+		return
+	}
+	conditionalCache[key] = e
+}
+
+// freshUntil works out when a response stops being fresh. Cache-Control
+// max-age wins over Expires, as in RFC 9111; Expires is measured against
+// the server's Date to tolerate clock skew. no-cache means revalidate every
+// time.
+func freshUntil(h http.Header, cc map[string]string, now time.Time) time.Time {
+	if _, ok := cc["no-cache"]; ok {
+		return time.Time{}
+	}
+	if v, ok := cc["max-age"]; ok {
+		secs, err := strconv.Atoi(v)
+		if err != nil || secs <= 0 {
+			return time.Time{}
+		}
+		age, _ := strconv.Atoi(h.Get("Age"))
+		return now.Add(time.Duration(secs-age) * time.Second)
+	}
+	expires, err := http.ParseTime(h.Get("Expires"))
+	if err != nil {
+		return time.Time{}
+	}
+	if date, err := http.ParseTime(h.Get("Date")); err == nil {
+		return now.Add(expires.Sub(date))
+	}
+	return expires
+}
+
+// parseCacheControl splits a Cache-Control header into lower-cased
+// directives; valueless directives map to "".
+func parseCacheControl(s string) map[string]string {
+	cc := map[string]string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return cc
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseCacheControl(t *testing.T) {
+	cc := parseCacheControl(`Public, MAX-AGE=300, no-cache="Set-Cookie", ,must-revalidate`)
+	want := map[string]string{"public": "", "max-age": "300", "no-cache": "Set-Cookie", "must-revalidate": ""}
+	if len(cc) != len(want) {
+		t.Fatalf("parseCacheControl = %v, want %v", cc, want)
+	}
+	for k, v := range want {
+		if got, ok := cc[k]; !ok || got != v {
+			t.Errorf("directive %q = %q (present %v), want %q", k, got, ok, v)
+		}
+	}
+	if len(parseCacheControl("")) != 0 {
+		t.Error("empty header should have no directives")
+	}
+}
+
+func TestFreshUntil(t *testing.T) {
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	httpTime := func(d time.Duration) string { return now.Add(d).Format(http.TimeFormat) }
+	tests := []struct {
+		name    string
+		header  map[string]string
+		want    time.Time
+		current bool
+	}{
+		{name: "max-age", header: map[string]string{"Cache-Control": "max-age=60"}, want: now.Add(time.Minute)},
+		{name: "max-age minus Age", header: map[string]string{"Cache-Control": "max-age=60", "Age": "20"}, want: now.Add(40 * time.Second)},
+		{name: "max-age zero", header: map[string]string{"Cache-Control": "max-age=0"}},
+		{name: "max-age invalid", header: map[string]string{"Cache-Control": "max-age=soon"}},
+		{name: "no-cache", header: map[string]string{"Cache-Control": "no-cache, max-age=60"}},
+		{
+			name:   "max-age beats Expires",
+			header: map[string]string{"Cache-Control": "max-age=60", "Expires": httpTime(time.Hour), "Date": httpTime(0)},
+			want:   now.Add(time.Minute),
+		},
+		{
+			// The server's clock is an hour behind; only the difference counts.
+			name:   "Expires against Date",
+			header: map[string]string{"Expires": httpTime(-time.Hour + 5*time.Minute), "Date": httpTime(-time.Hour)},
+			want:   now.Add(5 * time.Minute),
+		},
+		{name: "Expires without Date", header: map[string]string{"Expires": httpTime(10 * time.Minute)}, want: now.Add(10 * time.Minute)},
+		{name: "Expires invalid", header: map[string]string{"Expires": "0"}},
+		{name: "nothing", header: map[string]string{}},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		for k, v := range tt.header {
+			h.Set(k, v)
+		}
+		got := freshUntil(h, parseCacheControl(h.Get("Cache-Control")), now)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: freshUntil = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStoreConditional(t *testing.T) {
+	const key = "test:conditional"
+	t.Cleanup(func() {
+		conditionalMu.Lock()
+		delete(conditionalCache, key)
+		conditionalMu.Unlock()
+	})
+	resp := func(status int, header map[string]string) *http.Response {
+		h := http.Header{}
+		for k, v := range header {
+			h.Set(k, v)
+		}
+		return &http.Response{StatusCode: status, Header: h}
+	}
+
+	storeConditional(key, resp(http.StatusOK, map[string]string{
+		"ETag":          `"v1"`,
+		"Last-Modified": "Sat, 18 May 2024 11:00:00 GMT",
+	}), []byte("body"))
+	e, ok := lookupConditional(key)
+	if !ok || e.etag != `"v1"` || string(e.body) != "body" {
+		t.Fatalf("after 200: %+v (present %v)", e, ok)
+	}
+	if e.expires.After(time.Now()) {
+		t.Errorf("response without freshness info should need revalidation, fresh until %s", e.expires)
+	}
+
+	// A 304 refreshes freshness but may omit the validators.
+	storeConditional(key, resp(http.StatusNotModified, map[string]string{"Cache-Control": "max-age=60"}), e.body)
+	e, ok = lookupConditional(key)
+	if !ok || e.etag != `"v1"` || e.lastModified == "" || string(e.body) != "body" {
+		t.Fatalf("after 304: %+v (present %v), want the previous validators and body", e, ok)
+	}
+	if !e.expires.After(time.Now()) {
+		t.Errorf("after 304 with max-age: fresh until %s, want the future", e.expires)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	e.setValidators(req)
+	if req.Header.Get("If-None-Match") != `"v1"` || req.Header.Get("If-Modified-Since") == "" {
+		t.Errorf("validators = %v", req.Header)
+	}
+
+	storeConditional(key, resp(http.StatusOK, map[string]string{"Cache-Control": "no-store", "ETag": `"v2"`}), []byte("new"))
+	if _, ok := lookupConditional(key); ok {
+		t.Error("no-store response should drop the entry")
+	}
+
+	storeConditional(key, resp(http.StatusOK, nil), []byte("bare"))
+	if _, ok := lookupConditional(key); ok {
+		t.Error("response without validators or freshness should not be kept")
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,21 +18,62 @@ const (
 	minLiveInterval     = 5 * time.Second
 )
 
+const (
+	CommandCompare = "compare"
+	CommandStation = "station"
+)
+
+var commands = []string{CommandCompare, CommandStation, CommandNowcast}
+
 type Config struct {
-	APIProvider string
-	APIKey      string
-	City        string
-	Unit        string
-	Verbose     bool
-	Fancy       bool
-	NoColor     bool
-	JSON        bool
-	Quiet       bool
-	ShowVersion bool
-	Forecast    int
-	Live        bool
-	Art         bool
-	Interval    time.Duration
+	Command         string
+	APIProvider     string
+	APIProviders    []string
+	APIKey          string
+	APIKeys         map[string]string
+	Custom          map[string]string
+	BaseURLs        map[string]string
+	City            string
+	Cities          []string
+	Unit            string
+	Verbose         bool
+	Fancy           bool
+	NoColor         bool
+	JSON            bool
+	Quiet           bool
+	ShowVersion     bool
+	Forecast        int
+	Hourly          int
+	Live            bool
+	Art             bool
+	Consensus       bool
+	MetarFile       string
+	RecordDir       string
+	ReplayDir       string
+	StationListen   string
+	StationFile     string
+	StationPassword string
+	StationForecast string
+	CacheTTL        time.Duration
+	Retries         int
+	Proxy           string
+	CAFile          string
+	Timeout         time.Duration
+	ConnectTimeout  time.Duration
+	RetryDelay      time.Duration
+	RetryMaxDelay   time.Duration
+	NoCache         bool
+	Interval        time.Duration
+	Concurrency     int
+	RateLimit       float64
+	RateLimits      map[string]float64
+	Sort            string
+	ForecastColumns []string
+	NowcastWindow   time.Duration
+	NoAlerts        bool
+	AlertsOnly      bool
+	MeteoAlarm      string
+	MeteoAlarmArea  string
 }
 
 func MergeConfig(fileCfg Config, cliCfg Config) Config {
@@ -38,9 +81,10 @@ func MergeConfig(fileCfg Config, cliCfg Config) Config {
 
 	if cliCfg.APIKey != "" {
 		final.APIKey = cliCfg.APIKey
+		final.APIKeys = nil
 	}
-	if cliCfg.City != "" {
-		final.City = cliCfg.City
+	if len(cliCfg.Cities) > 0 {
+		final.Cities = cliCfg.Cities
 	}
 	if cliCfg.Unit != "" {
 		final.Unit = cliCfg.Unit
@@ -66,15 +110,76 @@ func MergeConfig(fileCfg Config, cliCfg Config) Config {
 	if cliCfg.Forecast != 0 {
 		final.Forecast = cliCfg.Forecast
 	}
+	if cliCfg.Hourly != 0 {
+		final.Hourly = cliCfg.Hourly
+	}
+	if cliCfg.NoAlerts {
+		final.NoAlerts = true
+	}
+	if cliCfg.AlertsOnly {
+		final.AlertsOnly = true
+	}
+	if cliCfg.NowcastWindow != 0 {
+		final.NowcastWindow = cliCfg.NowcastWindow
+	}
 	if cliCfg.Live {
 		final.Live = true
 	}
 	if cliCfg.Art {
 		final.Art = true
 	}
+	if cliCfg.Consensus {
+		final.Consensus = true
+	}
+	if cliCfg.MetarFile != "" {
+		final.MetarFile = cliCfg.MetarFile
+	}
+	if cliCfg.RecordDir != "" {
+		final.RecordDir = cliCfg.RecordDir
+	}
+	if cliCfg.ReplayDir != "" {
+		final.ReplayDir = cliCfg.ReplayDir
+	}
+	if cliCfg.StationListen != "" {
+		final.StationListen = cliCfg.StationListen
+	}
+	if cliCfg.StationFile != "" {
+		final.StationFile = cliCfg.StationFile
+	}
+	if cliCfg.CacheTTL != 0 {
+		final.CacheTTL = cliCfg.CacheTTL
+	}
+	if cliCfg.NoCache {
+		final.NoCache = true
+	}
+	if cliCfg.Retries >= 0 {
+		final.Retries = cliCfg.Retries
+	}
+	if cliCfg.Proxy != "" {
+		final.Proxy = cliCfg.Proxy
+	}
+	if cliCfg.CAFile != "" {
+		final.CAFile = cliCfg.CAFile
+	}
+	if cliCfg.Timeout != 0 {
+		final.Timeout = cliCfg.Timeout
+	}
 	if cliCfg.Interval != 0 {
 		final.Interval = cliCfg.Interval
 	}
+	if cliCfg.Concurrency != 0 {
+		final.Concurrency = cliCfg.Concurrency
+	}
+	if cliCfg.RateLimit != 0 {
+		final.RateLimit = cliCfg.RateLimit
+		final.RateLimits = nil
+	}
+	if cliCfg.Sort != "" {
+		final.Sort = cliCfg.Sort
+	}
+	if cliCfg.ForecastColumns != nil {
+		final.ForecastColumns = cliCfg.ForecastColumns
+	}
 
 	return final
 }
@@ -84,22 +189,55 @@ func GetConfig() (Config, error) {
 	fs.Usage = usage
 
 	cliConfigDir := flag.String("config", "", "directory containing the .wrep config file (default: $HOME)")
-	cliCity := flag.String("city", "", "override city")
+	var cliCities cityList
+	flag.Var(&cliCities, "city", "override city; repeat to report on several cities at once")
 	cliUnit := flag.String("unit", "", "override unit: metric or imperial")
-	cliAPIKey := flag.String("apikey", "", "override API key (WeatherAPI only)")
-	cliAPIProvider := flag.String("apiprovider", "", "API provider: wttr.in or weatherapi")
+	cliAPIKey := flag.String("apikey", "", "override API key for keyed providers")
+	cliAPIProvider := flag.String("apiprovider", "", "API provider, or a comma-separated fallback chain (one of: "+strings.Join(ProviderNames(), ", ")+", or exec:PATH)")
 	cliVerbose := flag.Bool("v", false, "verbose output")
 	cliFancy := flag.Bool("fancy", false, "fancy output with colors and emojis")
 	cliNoColor := flag.Bool("no-color", false, "disable color escapes (also honors NO_COLOR env)")
 	cliJSON := flag.Bool("json", false, "emit raw JSON instead of formatted output")
 	cliQuiet := flag.Bool("q", false, "suppress non-error messages")
 	cliForecast := flag.Int("f", 0, "show an N-day forecast (e.g. -f 3)")
+	cliHourly := flag.Int("hourly", 0, "show an hourly forecast for the next N hours (e.g. -hourly 12)")
 	cliLive := flag.Bool("live", false, "live mode: refresh weather on an interval until interrupted")
 	cliArt := flag.Bool("art", false, "neofetch-style display: weather info next to ASCII art")
+	cliConsensus := flag.Bool("consensus", false, "query every configured provider and merge the results")
+	cliMetarFile := flag.String("metar-file", "", "read raw METAR/TAF text from this file (\"-\" for stdin) instead of the network")
+	cliRecordDir := flag.String("record", "", "save every provider response to this directory")
+	cliReplayDir := flag.String("replay", "", "answer from responses saved with -record instead of the network")
+	cliStationListen := flag.String("listen", "", "address for wrep station to accept uploads on (default :8080)")
+	cliStationFile := flag.String("station-file", "", "where wrep station stores the latest observation (default: user cache dir)")
+	cliCacheTTLStr := flag.String("cache-ttl", "", "reuse a cached report younger than this Go duration (e.g. 5m) instead of fetching")
+	cliNoCache := flag.Bool("no-cache", false, "neither read nor write the on-disk report cache")
+	cliRetries := flag.Int("retries", -1, "retry network errors, 429 and 5xx responses this many times (default 2)")
+	cliProxy := flag.String("proxy", "", "HTTP(S) proxy URL (default: HTTPS_PROXY/HTTP_PROXY from the environment)")
+	cliCAFile := flag.String("ca-file", "", "PEM file(s), comma-separated, with extra CA certificates to trust")
+	cliTimeoutStr := flag.String("timeout", "", "overall per-request timeout as a Go duration (default 30s)")
+	cliNoAlerts := flag.Bool("no-alerts", false, "don't fetch or show severe weather alerts")
+	cliAlertsOnly := flag.Bool("alerts-only", false, "show only active severe weather alerts")
+	cliWindowStr := flag.String("window", "", "how far ahead wrep nowcast looks, as a Go duration (default 2h, max 12h)")
 	cliIntervalStr := flag.String("interval", "", "live-mode refresh interval as a Go duration (e.g. 30s, 5m); min 5s")
+	cliConcurrency := flag.Int("concurrency", 0, "with several cities, fetch at most this many at once (default 4)")
+	cliRateLimit := flag.Float64("rate-limit", 0, "start at most this many fetches per second per provider (default: unlimited)")
+	cliSort := flag.String("sort", "", "order of the multi-city table: name or temp (default name)")
+	cliColumns := flag.String("columns", "", "extra forecast columns, comma-separated, or \"none\" (from: "+strings.Join(forecastColumnNames(), ", ")+"; default "+strings.Join(defaultForecastColumns, ",")+")")
 	cliShowVersion := flag.Bool("V", false, "print version and exit")
 	cliShowVersionLong := flag.Bool("version", false, "print version and exit")
-	flag.Parse()
+
+	args := os.Args[1:]
+	var command string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if command != "" && !slices.Contains(commands, command) {
+		return Config{}, fmt.Errorf("unknown command %q (want one of: %s)", command, strings.Join(commands, ", "))
+	}
 
 	if *cliShowVersion || *cliShowVersionLong {
 		return Config{ShowVersion: true}, nil
@@ -113,21 +251,67 @@ func GetConfig() (Config, error) {
 		}
 		cliInterval = d
 	}
+	var cliWindow time.Duration
+	if s := strings.TrimSpace(*cliWindowStr); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid -window %q: %w", s, err)
+		}
+		cliWindow = d
+	}
+	var cliTimeout time.Duration
+	if s := strings.TrimSpace(*cliTimeoutStr); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid -timeout %q: %w", s, err)
+		}
+		cliTimeout = d
+	}
+	var cliCacheTTL time.Duration
+	if s := strings.TrimSpace(*cliCacheTTLStr); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid -cache-ttl %q: %w", s, err)
+		}
+		cliCacheTTL = d
+	}
 
 	cliConfig := Config{
-		APIProvider: *cliAPIProvider,
-		APIKey:      *cliAPIKey,
-		City:        *cliCity,
-		Unit:        *cliUnit,
-		Verbose:     *cliVerbose,
-		Fancy:       *cliFancy,
-		NoColor:     *cliNoColor,
-		JSON:        *cliJSON,
-		Quiet:       *cliQuiet,
-		Forecast:    *cliForecast,
-		Live:        *cliLive,
-		Art:         *cliArt,
-		Interval:    cliInterval,
+		APIProvider:   *cliAPIProvider,
+		APIKey:        *cliAPIKey,
+		Cities:        cliCities,
+		Unit:          *cliUnit,
+		Verbose:       *cliVerbose,
+		Fancy:         *cliFancy,
+		NoColor:       *cliNoColor,
+		JSON:          *cliJSON,
+		Quiet:         *cliQuiet,
+		Forecast:      *cliForecast,
+		Hourly:        *cliHourly,
+		NowcastWindow: cliWindow,
+		NoAlerts:      *cliNoAlerts,
+		AlertsOnly:    *cliAlertsOnly,
+		Live:          *cliLive,
+		Art:           *cliArt,
+		Consensus:     *cliConsensus,
+		MetarFile:     *cliMetarFile,
+		RecordDir:     *cliRecordDir,
+		ReplayDir:     *cliReplayDir,
+		StationListen: *cliStationListen,
+		StationFile:   *cliStationFile,
+		CacheTTL:      cliCacheTTL,
+		NoCache:       *cliNoCache,
+		Retries:       *cliRetries,
+		Proxy:         *cliProxy,
+		CAFile:        *cliCAFile,
+		Timeout:       cliTimeout,
+		Interval:      cliInterval,
+		Concurrency:   *cliConcurrency,
+		RateLimit:     *cliRateLimit,
+		Sort:          *cliSort,
+	}
+	if s := strings.TrimSpace(*cliColumns); s != "" {
+		cliConfig.ForecastColumns = splitList(s)
 	}
 
 	configDir := *cliConfigDir
@@ -152,6 +336,19 @@ func GetConfig() (Config, error) {
 	}
 
 	final := MergeConfig(fileConfig, cliConfig)
+	final.Command = command
+	if len(final.Cities) > 0 {
+		final.City = final.Cities[0]
+	} else if final.City != "" {
+		final.Cities = []string{final.City}
+	}
+
+	if cliConfig.MetarFile != "" && cliConfig.APIProvider == "" {
+		final.APIProvider = ProviderMETAR
+	}
+	if slices.ContainsFunc(final.Cities, isDemoCity) && cliConfig.APIProvider == "" {
+		final.APIProvider = ProviderDemo
+	}
 
 	if final.APIProvider == "" {
 		final.APIProvider = ProviderWttr
@@ -159,17 +356,53 @@ func GetConfig() (Config, error) {
 	if final.Unit == "" {
 		final.Unit = UnitMetric
 	}
-	if !validProvider(final.APIProvider) {
-		return Config{}, fmt.Errorf("invalid apiProvider %q (want %q or %q)", final.APIProvider, ProviderWttr, ProviderWeatherAPI)
+	// apiProvider may be a fallback chain; APIProvider keeps its head.
+	final.APIProviders = splitList(final.APIProvider)
+	if len(final.APIProviders) == 0 {
+		return Config{}, fmt.Errorf("invalid apiProvider %q (want one of %s, or exec:PATH)", final.APIProvider, providerList())
+	}
+	for _, name := range final.APIProviders {
+		if _, ok := LookupProvider(name); !ok {
+			return Config{}, fmt.Errorf("invalid apiProvider %q (want one of %s, or exec:PATH)", name, providerList())
+		}
 	}
+	final.APIProvider = final.APIProviders[0]
 	if !validUnit(final.Unit) {
 		return Config{}, fmt.Errorf("invalid unit %q (want %q or %q)", final.Unit, UnitMetric, UnitImperial)
 	}
-	if final.City == "" {
+	if final.StationListen == "" {
+		final.StationListen = defaultStationListen
+	}
+	if final.StationForecast == "" {
+		final.StationForecast = ProviderOpenMeteo
+	}
+	if p, ok := LookupProvider(final.StationForecast); !ok || p.Name() == ProviderStation {
+		return Config{}, fmt.Errorf("invalid stationForecast %q (want a provider other than %q)", final.StationForecast, ProviderStation)
+	}
+	if final.RecordDir != "" && final.ReplayDir != "" {
+		return Config{}, errors.New("-record and -replay cannot be used together")
+	}
+	if final.City == "" && final.MetarFile == "" && final.Command != CommandStation {
 		return Config{}, errors.New("config missing required field: defaultCity (or pass -city)")
 	}
-	if final.APIProvider == ProviderWeatherAPI && (final.APIKey == "" || final.APIKey == "your_api_key_here") {
-		return Config{}, errors.New("apiProvider=weatherapi requires apiKey (set in ~/.wrep or pass -apikey)")
+	for _, name := range final.APIProviders {
+		provider, _ := LookupProvider(name)
+		if provider.Capabilities().NeedsAPIKey && apiKeyFor(final, name) == "" {
+			return Config{}, fmt.Errorf("apiProvider=%s requires apiKey (set apiKey or apiKey.%s in ~/.wrep, or pass -apikey)", name, name)
+		}
+		if v, ok := provider.(Validator); ok {
+			cities := final.Cities
+			if len(cities) == 0 {
+				cities = []string{final.City}
+			}
+			for _, city := range cities {
+				c := final
+				c.City = city
+				if err := v.Validate(c); err != nil {
+					return Config{}, err
+				}
+			}
+		}
 	}
 	if final.JSON && final.Fancy {
 		final.Fancy = false
@@ -183,9 +416,103 @@ func GetConfig() (Config, error) {
 		}
 		final.Forecast = 0
 	}
+	if final.Art && final.Hourly > 0 {
+		if !final.Quiet {
+			fmt.Fprintln(os.Stderr, "wrep: -art does not support -hourly; ignoring hourly forecast")
+		}
+		final.Hourly = 0
+	}
+	// Providers slice the day into different slots, so hourly forecasts
+	// are neither merged nor compared.
+	if (final.Consensus || final.Command == CommandCompare) && final.Hourly > 0 {
+		mode := "-consensus"
+		if final.Command == CommandCompare {
+			mode = CommandCompare
+		}
+		if !final.Quiet {
+			fmt.Fprintf(os.Stderr, "wrep: %s does not support -hourly; ignoring hourly forecast\n", mode)
+		}
+		final.Hourly = 0
+	}
+	if final.Hourly < 0 {
+		return Config{}, fmt.Errorf("-hourly must not be negative (got %d)", final.Hourly)
+	}
+	if len(final.Cities) > 1 {
+		if final.Command == CommandCompare || final.Command == CommandNowcast {
+			return Config{}, fmt.Errorf("%s takes a single city", final.Command)
+		}
+		if final.AlertsOnly {
+			return Config{}, errors.New("-alerts-only takes a single city")
+		}
+		if final.Art {
+			if !final.Quiet {
+				fmt.Fprintln(os.Stderr, "wrep: -art shows a single city; using the multi-city table")
+			}
+			final.Art = false
+		}
+	}
 	if final.Live && final.Interval == 0 {
 		final.Interval = defaultLiveInterval
 	}
+	if final.AlertsOnly && final.NoAlerts {
+		return Config{}, errors.New("-alerts-only and -no-alerts cannot be used together")
+	}
+	if final.AlertsOnly && final.Command != "" {
+		return Config{}, fmt.Errorf("-alerts-only does not apply to %s", final.Command)
+	}
+	if final.NowcastWindow == 0 {
+		final.NowcastWindow = defaultNowcastWindow
+	}
+	if final.NowcastWindow < nowcastStep || final.NowcastWindow > maxNowcastWindow {
+		return Config{}, fmt.Errorf("nowcast window must be between %s and %s (got %s)", nowcastStep, maxNowcastWindow, final.NowcastWindow)
+	}
+	if final.Concurrency == 0 {
+		final.Concurrency = defaultConcurrency
+	}
+	if final.Concurrency < 0 {
+		return Config{}, fmt.Errorf("concurrency must be at least 1 (got %d)", final.Concurrency)
+	}
+	if final.RateLimit < 0 {
+		return Config{}, fmt.Errorf("rateLimit must not be negative (got %g)", final.RateLimit)
+	}
+	if final.ForecastColumns == nil {
+		final.ForecastColumns = defaultForecastColumns
+	}
+	if len(final.ForecastColumns) == 1 && final.ForecastColumns[0] == "none" {
+		final.ForecastColumns = []string{}
+	}
+	for _, name := range final.ForecastColumns {
+		if _, ok := lookupForecastColumn(name); !ok {
+			return Config{}, fmt.Errorf("invalid forecast column %q (want one of: %s, or none)", name, strings.Join(forecastColumnNames(), ", "))
+		}
+	}
+	if final.Sort == "" {
+		final.Sort = SortName
+	}
+	if final.Sort != SortName && final.Sort != SortTemp {
+		return Config{}, fmt.Errorf("invalid sort %q (want %q or %q)", final.Sort, SortName, SortTemp)
+	}
+	if final.Retries < 0 {
+		final.Retries = defaultRetries
+	}
+	if final.RetryDelay == 0 {
+		final.RetryDelay = defaultRetryDelay
+	}
+	if final.RetryMaxDelay == 0 {
+		final.RetryMaxDelay = defaultRetryMaxDelay
+	}
+	if final.RetryMaxDelay < final.RetryDelay {
+		final.RetryMaxDelay = final.RetryDelay
+	}
+	if err := validateTimeouts(&final); err != nil {
+		return Config{}, err
+	}
+	if err := validateBaseURLs(final.BaseURLs); err != nil {
+		return Config{}, err
+	}
+	if final.CacheTTL < 0 {
+		return Config{}, fmt.Errorf("cacheTTL must not be negative (got %s)", final.CacheTTL)
+	}
 	if final.Interval > 0 && final.Interval < minLiveInterval {
 		return Config{}, fmt.Errorf("interval must be at least %s (got %s)", minLiveInterval, final.Interval)
 	}
@@ -200,7 +527,7 @@ func readConfigFile(path string) (Config, error) {
 	}
 	defer f.Close()
 
-	var cfg Config
+	cfg := Config{Retries: -1}
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -214,11 +541,46 @@ func readConfigFile(path string) (Config, error) {
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
+		if name, ok := strings.CutPrefix(key, "apiKey."); ok {
+			if cfg.APIKeys == nil {
+				cfg.APIKeys = map[string]string{}
+			}
+			cfg.APIKeys[name] = value
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, "baseURL."); ok {
+			if cfg.BaseURLs == nil {
+				cfg.BaseURLs = map[string]string{}
+			}
+			cfg.BaseURLs[name] = value
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, "rateLimit."); ok {
+			r, err := strconv.ParseFloat(value, 64)
+			if err != nil || r < 0 {
+				return Config{}, fmt.Errorf("invalid %s %q in config (want requests per second >= 0)", key, value)
+			}
+			if cfg.RateLimits == nil {
+				cfg.RateLimits = map[string]float64{}
+			}
+			cfg.RateLimits[name] = r
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, "custom."); ok {
+			if cfg.Custom == nil {
+				cfg.Custom = map[string]string{}
+			}
+			cfg.Custom[name] = value
+			continue
+		}
+
 		switch key {
 		case "apiKey":
 			cfg.APIKey = value
 		case "defaultCity":
 			cfg.City = value
+		case "cities":
+			cfg.Cities = splitList(value)
 		case "units":
 			cfg.Unit = value
 		case "apiProvider":
@@ -237,12 +599,91 @@ func readConfigFile(path string) (Config, error) {
 			cfg.Live = parseBool(value)
 		case "art":
 			cfg.Art = parseBool(value)
+		case "consensus":
+			cfg.Consensus = parseBool(value)
+		case "stationListen":
+			cfg.StationListen = value
+		case "stationFile":
+			cfg.StationFile = value
+		case "stationPassword":
+			cfg.StationPassword = value
+		case "stationForecast":
+			cfg.StationForecast = value
+		case "cacheTTL":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid cacheTTL %q in config: %w", value, err)
+			}
+			cfg.CacheTTL = d
+		case "cache":
+			cfg.NoCache = !parseBool(value)
+		case "proxy":
+			cfg.Proxy = value
+		case "caFile":
+			cfg.CAFile = value
+		case "timeout", "connectTimeout":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid %s %q in config: %w", key, value, err)
+			}
+			if key == "timeout" {
+				cfg.Timeout = d
+			} else {
+				cfg.ConnectTimeout = d
+			}
+		case "retries":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return Config{}, fmt.Errorf("invalid retries %q in config (want a number >= 0)", value)
+			}
+			cfg.Retries = n
+		case "retryDelay", "retryMaxDelay":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return Config{}, fmt.Errorf("invalid %s %q in config (want a positive Go duration)", key, value)
+			}
+			if key == "retryDelay" {
+				cfg.RetryDelay = d
+			} else {
+				cfg.RetryMaxDelay = d
+			}
 		case "interval":
 			d, err := time.ParseDuration(value)
 			if err != nil {
 				return Config{}, fmt.Errorf("invalid interval %q in config: %w", value, err)
 			}
 			cfg.Interval = d
+		case "concurrency":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return Config{}, fmt.Errorf("invalid concurrency %q in config (want a number >= 1)", value)
+			}
+			cfg.Concurrency = n
+		case "rateLimit":
+			r, err := strconv.ParseFloat(value, 64)
+			if err != nil || r < 0 {
+				return Config{}, fmt.Errorf("invalid rateLimit %q in config (want requests per second >= 0)", value)
+			}
+			cfg.RateLimit = r
+		case "sort":
+			cfg.Sort = value
+		case "alerts":
+			cfg.NoAlerts = !parseBool(value)
+		case "meteoalarm":
+			cfg.MeteoAlarm = value
+		case "meteoalarmArea":
+			cfg.MeteoAlarmArea = value
+		case "nowcastWindow":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return Config{}, fmt.Errorf("invalid nowcastWindow %q in config: %w", value, err)
+			}
+			cfg.NowcastWindow = d
+		case "forecastColumns":
+			cfg.ForecastColumns = splitList(value)
+			if cfg.ForecastColumns == nil {
+				cfg.ForecastColumns = []string{"none"}
+			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
@@ -260,6 +701,7 @@ func GenerateDefaultConfig(configPath string) error {
 
 	const defaultContent = `# wrep config - flags on the command line override these values.
 apiKey=your_api_key_here
+# apiKey.openweathermap=your_owm_key_here
 defaultCity=Moscow
 units=metric
 apiProvider=wttr.in
@@ -269,6 +711,8 @@ noColor=off
 live=off
 art=off
 # interval=60s
+# cities=Berlin,Paris,Tokyo
+# cacheTTL=5m
 `
 	_, err = f.WriteString(defaultContent)
 	return err
@@ -282,8 +726,28 @@ func parseBool(s string) bool {
 	return false
 }
 
-func validProvider(p string) bool {
-	return p == ProviderWttr || p == ProviderWeatherAPI
+// apiKeyFor returns the key configured for provider via apiKey.<provider>,
+// falling back to the shared apiKey. The placeholder written by
+// GenerateDefaultConfig counts as unset.
+func apiKeyFor(config Config, provider string) string {
+	key := config.APIKeys[provider]
+	if key == "" {
+		key = config.APIKey
+	}
+	if key == "your_api_key_here" {
+		return ""
+	}
+	return key
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
 }
 
 func validUnit(u string) bool {
@@ -296,6 +760,9 @@ func usage() {
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "Usage:")
 	fmt.Fprintln(out, "  wrep [flags]")
+	fmt.Fprintln(out, "  wrep compare [flags]   one column per provider, differences highlighted")
+	fmt.Fprintln(out, "  wrep station [flags]   receive uploads from a personal weather station")
+	fmt.Fprintln(out, "  wrep nowcast [flags]   will it rain in the next couple of hours?")
 	fmt.Fprintln(out)
 	fmt.Fprintln(out, "Flags:")
 	flag.PrintDefaults()
@@ -303,7 +770,21 @@ func usage() {
 	fmt.Fprintln(out, "Examples:")
 	fmt.Fprintln(out, "  wrep -city=Berlin -fancy")
 	fmt.Fprintln(out, "  wrep -f 3 -fancy")
+	fmt.Fprintln(out, "  wrep -hourly 12")
+	fmt.Fprintln(out, "  wrep nowcast -city=Amsterdam -window=1h")
+	fmt.Fprintln(out, "  wrep -alerts-only -apiprovider=nws -city=Miami")
 	fmt.Fprintln(out, "  wrep -apiprovider=weatherapi -apikey=$KEY -city=Tokyo -unit=imperial")
+	fmt.Fprintln(out, "  wrep -apiprovider=weatherapi,wttr.in -apikey=$KEY")
+	fmt.Fprintln(out, "  wrep -consensus -apiprovider=wttr.in,open-meteo,met.no -f 3")
+	fmt.Fprintln(out, "  wrep compare -city=Lisbon -f 3")
+	fmt.Fprintln(out, "  wrep -apiprovider=metar -city=EDDB -f 1")
+	fmt.Fprintln(out, "  pbpaste | wrep -metar-file=- -f 1")
+	fmt.Fprintln(out, "  wrep -city=demo:stormy -art -fancy")
+	fmt.Fprintln(out, "  wrep -city=Berlin -city=Paris -city=Tokyo -sort=temp -f 2")
+	fmt.Fprintln(out, "  wrep -record=./bug-123 -f 3")
+	fmt.Fprintln(out, "  wrep -replay=./bug-123 -f 3")
+	fmt.Fprintln(out, "  wrep station -listen=:8080")
+	fmt.Fprintln(out, "  wrep -apiprovider=station -f 3")
 	fmt.Fprintln(out, "  wrep -json | jq")
 	fmt.Fprintln(out, "  wrep -live -interval=30s -fancy")
 	fmt.Fprintln(out, "  wrep -live -interval=1m -json | jq .")
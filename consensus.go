@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type providerResult struct {
+	name string
+	info WeatherInfo
+	err  error
+}
+
+// pollProviders returns the providers to query in consensus and compare
+// modes: the configured chain when it names more than one, otherwise every
+// registered provider that can run with the current config.
+func pollProviders(config Config) []string {
+	if len(config.APIProviders) > 1 {
+		return config.APIProviders
+	}
+	var names []string
+	for _, name := range ProviderNames() {
+		p, _ := LookupProvider(name)
+		// Local sources (demo data, your own station) say nothing
+		// about an arbitrary city.
+		if p.Capabilities().Local {
+			continue
+		}
+		if p.Capabilities().NeedsAPIKey && apiKeyFor(config, name) == "" {
+			continue
+		}
+		if v, ok := p.(Validator); ok && v.Validate(config) != nil {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// fetchAll queries each named provider concurrently. Results are returned in
+// the order of names regardless of which provider answered first.
+func fetchAll(ctx context.Context, config Config, names []string) []providerResult {
+	results := make([]providerResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i].name = name
+			p, ok := LookupProvider(name)
+			if !ok {
+				results[i].err = fmt.Errorf("unknown provider %q", name)
+				return
+			}
+			cfg := config
+			cfg.APIProvider = name
+			info, err := fetchFrom(ctx, p, cfg)
+			if ctx.Err() == nil {
+				recordProviderResult(name, err)
+			}
+			info.Provider = name
+			results[i].info, results[i].err = info, err
+		}(i, name)
+	}
+	wg.Wait()
+	return results
+}
+
+// FetchConsensus queries every configured provider at once and merges their
+// reports: temperatures and UV are medians, the WeatherType is the majority
+// vote, and the spread between providers is kept alongside.
+func FetchConsensus(ctx context.Context, config Config) (WeatherInfo, error) {
+	names := pollProviders(config)
+	results := fetchAll(ctx, config, names)
+	if err := ctx.Err(); err != nil {
+		return WeatherInfo{}, err
+	}
+
+	var infos []WeatherInfo
+	failed := map[string]string{}
+	for _, r := range results {
+		if r.err != nil {
+			failed[r.name] = r.err.Error()
+			if !config.Quiet {
+				fmt.Fprintf(os.Stderr, "wrep: %s failed: %v\n", r.name, r.err)
+			}
+			continue
+		}
+		infos = append(infos, r.info)
+	}
+	if len(infos) == 0 {
+		msgs := make([]string, 0, len(results))
+		for _, r := range results {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", r.name, r.err))
+		}
+		if len(msgs) == 0 {
+			return WeatherInfo{}, errors.New("no providers available for consensus")
+		}
+		return WeatherInfo{}, fmt.Errorf("all providers failed: %s", strings.Join(msgs, "; "))
+	}
+
+	merged := mergeInfos(infos)
+	if len(failed) > 0 {
+		merged.Consensus.Failed = failed
+	}
+	if err := addMeteoAlarm(ctx, config, &merged); err != nil {
+		return WeatherInfo{}, err
+	}
+	return merged, nil
+}
+
+func mergeInfos(infos []WeatherInfo) WeatherInfo {
+	var tempC, tempF, uv []float64
+	types := make([]WeatherType, 0, len(infos))
+	providers := make([]string, 0, len(infos))
+	var alerts []Alert
+	for _, info := range infos {
+		alerts = append(alerts, info.Alerts...)
+		tempC = append(tempC, info.TempC)
+		tempF = append(tempF, info.TempF)
+		uv = append(uv, info.UVIndex)
+		types = append(types, info.Type)
+		providers = append(providers, info.Provider)
+	}
+
+	wt, agreement := majorityType(types)
+	merged := WeatherInfo{
+		TempC:       median(tempC),
+		TempF:       median(tempF),
+		UVIndex:     median(uv),
+		Type:        wt,
+		Description: descriptionFor(wt, infos),
+		Consensus: &Consensus{
+			Providers:   providers,
+			TempSpreadC: spread(tempC),
+			TempSpreadF: spread(tempF),
+			UVSpread:    spread(uv),
+			Agreement:   agreement,
+		},
+	}
+	merged.Details = mergeDetails(infos)
+	merged.Forecast = mergeForecasts(infos)
+	// Any provider's alert is worth showing.
+	merged.Alerts = activeAlerts(alerts, time.Now())
+	return merged
+}
+
+// mergeDetails takes the median of each detail across the providers that
+// report details at all.
+func mergeDetails(infos []WeatherInfo) *Details {
+	var ds []*Details
+	for _, info := range infos {
+		if info.Details != nil {
+			ds = append(ds, info.Details)
+		}
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+	med := func(field func(*Details) float64) float64 {
+		xs := make([]float64, len(ds))
+		for i, d := range ds {
+			xs[i] = field(d)
+		}
+		return median(xs)
+	}
+	return &Details{
+		FeelsLikeC: med(func(d *Details) float64 { return d.FeelsLikeC }),
+		FeelsLikeF: med(func(d *Details) float64 { return d.FeelsLikeF }),
+		WindKph:    med(func(d *Details) float64 { return d.WindKph }),
+		WindMph:    med(func(d *Details) float64 { return d.WindMph }),
+		GustKph:    med(func(d *Details) float64 { return d.GustKph }),
+		GustMph:    med(func(d *Details) float64 { return d.GustMph }),
+		// The median of bearings means nothing across north, so the
+		// direction comes from the first provider.
+		WindDirDeg:      ds[0].WindDirDeg,
+		WindDir:         ds[0].WindDir,
+		Humidity:        int(math.Round(med(func(d *Details) float64 { return float64(d.Humidity) }))),
+		PressureMb:      med(func(d *Details) float64 { return d.PressureMb }),
+		PressureIn:      med(func(d *Details) float64 { return d.PressureIn }),
+		VisibilityKm:    med(func(d *Details) float64 { return d.VisibilityKm }),
+		VisibilityMiles: med(func(d *Details) float64 { return d.VisibilityMiles }),
+		CloudCover:      int(math.Round(med(func(d *Details) float64 { return float64(d.CloudCover) }))),
+		PrecipMM:        med(func(d *Details) float64 { return d.PrecipMM }),
+		PrecipIn:        med(func(d *Details) float64 { return d.PrecipIn }),
+	}
+}
+
+// mergeForecasts lines up forecast days from every provider by date and
+// merges each date the same way as the current conditions. Missing lows
+// and highs stay out of the median.
+func mergeForecasts(infos []WeatherInfo) []ForecastDay {
+	byDate := map[int64][]ForecastDay{}
+	var dates []int64
+	for _, info := range infos {
+		for _, d := range info.Forecast {
+			k := d.Date.Unix()
+			if _, ok := byDate[k]; !ok {
+				dates = append(dates, k)
+			}
+			byDate[k] = append(byDate[k], d)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i] < dates[j] })
+
+	out := make([]ForecastDay, 0, len(dates))
+	for _, k := range dates {
+		days := byDate[k]
+		var minC, maxC, minF, maxF []float64
+		var types []WeatherType
+		for _, d := range days {
+			if !d.NoMin {
+				minC = append(minC, d.MinTempC)
+				minF = append(minF, d.MinTempF)
+			}
+			if !d.NoMax {
+				maxC = append(maxC, d.MaxTempC)
+				maxF = append(maxF, d.MaxTempF)
+			}
+			types = append(types, d.Type)
+		}
+		wt, agreement := majorityType(types)
+		desc := days[0].Description
+		for _, d := range days {
+			if d.Type == wt {
+				desc = d.Description
+				break
+			}
+		}
+		out = append(out, ForecastDay{
+			Date:        days[0].Date,
+			MinTempC:    median(minC),
+			MaxTempC:    median(maxC),
+			MinTempF:    median(minF),
+			MaxTempF:    median(maxF),
+			NoMin:       len(minC) == 0,
+			NoMax:       len(maxC) == 0,
+			Description: desc,
+			Type:        wt,
+			Details:     mergeDayDetails(days),
+			Spread: &Spread{
+				Sources:   len(days),
+				MinTempC:  spread(minC),
+				MaxTempC:  spread(maxC),
+				MinTempF:  spread(minF),
+				MaxTempF:  spread(maxF),
+				Agreement: agreement,
+			},
+		})
+	}
+	return out
+}
+
+// mergeDayDetails is mergeDetails for one forecast date. Astronomy times
+// come from the first provider that has them.
+func mergeDayDetails(days []ForecastDay) *DayDetails {
+	var ds []*DayDetails
+	for _, d := range days {
+		if d.Details != nil {
+			ds = append(ds, d.Details)
+		}
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+	med := func(field func(*DayDetails) float64) float64 {
+		xs := make([]float64, len(ds))
+		for i, d := range ds {
+			xs[i] = field(d)
+		}
+		return median(xs)
+	}
+	m := &DayDetails{
+		ChanceOfRain: int(math.Round(med(func(d *DayDetails) float64 { return float64(d.ChanceOfRain) }))),
+		ChanceOfSnow: int(math.Round(med(func(d *DayDetails) float64 { return float64(d.ChanceOfSnow) }))),
+		PrecipMM:     med(func(d *DayDetails) float64 { return d.PrecipMM }),
+		PrecipIn:     med(func(d *DayDetails) float64 { return d.PrecipIn }),
+		MaxWindKph:   med(func(d *DayDetails) float64 { return d.MaxWindKph }),
+		MaxWindMph:   med(func(d *DayDetails) float64 { return d.MaxWindMph }),
+		AvgHumidity:  int(math.Round(med(func(d *DayDetails) float64 { return float64(d.AvgHumidity) }))),
+		UVIndex:      med(func(d *DayDetails) float64 { return d.UVIndex }),
+	}
+	for _, d := range ds {
+		if m.Sunrise == "" && d.Sunrise != "" {
+			m.Sunrise, m.Sunset, m.Moonrise = d.Sunrise, d.Sunset, d.Moonrise
+		}
+	}
+	return m
+}
+
+func descriptionFor(wt WeatherType, infos []WeatherInfo) string {
+	for _, info := range infos {
+		if info.Type == wt {
+			return info.Description
+		}
+	}
+	return infos[0].Description
+}
+
+// majorityType returns the most common type and the share of votes it got.
+// Ties go to the type reported first, i.e. by the earlier provider.
+func majorityType(types []WeatherType) (WeatherType, float64) {
+	if len(types) == 0 {
+		return Unknown, 0
+	}
+	counts := map[WeatherType]int{}
+	best, bestN := types[0], 0
+	for _, t := range types {
+		counts[t]++
+	}
+	for _, t := range types {
+		if counts[t] > bestN {
+			best, bestN = t, counts[t]
+		}
+	}
+	return best, float64(bestN) / float64(len(types))
+}
+
+func median(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	s := append([]float64(nil), xs...)
+	sort.Float64s(s)
+	mid := len(s) / 2
+	if len(s)%2 == 1 {
+		return s[mid]
+	}
+	return (s[mid-1] + s[mid]) / 2
+}
+
+func spread(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	lo, hi := xs[0], xs[0]
+	for _, x := range xs[1:] {
+		lo = min(lo, x)
+		hi = max(hi, x)
+	}
+	return hi - lo
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ProviderCustom = "custom"
+
+// customProvider reads any JSON weather API described by the custom.* keys
+// in the config file:
+//
+//	custom.url=https://weather.internal/v1/now?city={city}&key={key}
+//	custom.temp_c=$.current.temperature
+//	custom.description=$.current.summary
+//	custom.forecast=$.daily
+//	custom.forecast.max_temp_c=high
+//
+// Field mappings are paths into the response; see lookupPath.
+type customProvider struct{}
+
+func init() {
+	RegisterProvider(customProvider{})
+}
+
+// weatherTypeNames are the values custom.type.<value> may map to.
+var weatherTypeNames = map[string]WeatherType{
+	"unknown":     Unknown,
+	"sunny":       Sunny,
+	"cloudy":      Cloudy,
+	"rainy":       Rainy,
+	"snowy":       Snowy,
+	"stormy":      Stormy,
+	"foggy":       Foggy,
+	"clear-night": ClearNight,
+}
+
+func (customProvider) Name() string { return ProviderCustom }
+
+func (customProvider) Capabilities() Capabilities {
+	return Capabilities{}
+}
+
+// Validate reports missing or malformed custom.* keys so a bad mapping is
+// caught at startup rather than on the first fetch.
+func (customProvider) Validate(config Config) error {
+	if config.Custom["url"] == "" {
+		return errors.New("apiProvider=custom requires custom.url in ~/.wrep")
+	}
+	if config.Custom["temp_c"] == "" && config.Custom["temp_f"] == "" {
+		return errors.New("apiProvider=custom requires custom.temp_c or custom.temp_f in ~/.wrep")
+	}
+	for key, value := range config.Custom {
+		name, ok := strings.CutPrefix(key, "type.")
+		if !ok {
+			continue
+		}
+		if _, ok := weatherTypeNames[strings.ToLower(value)]; !ok {
+			return fmt.Errorf("invalid custom.type.%s=%s (want one of: sunny, cloudy, rainy, snowy, stormy, foggy, clear-night, unknown)", name, value)
+		}
+	}
+	return nil
+}
+
+func (customProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	tmpl := config.Custom["url"]
+	replacements := []string{
+		"{city}", url.PathEscape(config.City),
+		"{key}", url.QueryEscape(apiKeyFor(config, ProviderCustom)),
+	}
+	if strings.Contains(tmpl, "{lat}") || strings.Contains(tmpl, "{lon}") {
+		c, err := geocodeOpenMeteo(ctx, config)
+		if err != nil {
+			return nil, err
+		}
+		replacements = append(replacements, "{lat}", formatCoord(c.Lat), "{lon}", formatCoord(c.Lon))
+	}
+	return newRequest(ctx, strings.NewReplacer(replacements...).Replace(tmpl))
+}
+
+func (customProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+func (customProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode custom response: %w", err)
+	}
+	m := config.Custom
+
+	var info WeatherInfo
+	var err error
+	if info.TempC, info.TempF, err = customTemps(doc, m["temp_c"], m["temp_f"]); err != nil {
+		return WeatherInfo{}, err
+	}
+	if m["uv_index"] != "" {
+		if info.UVIndex, err = customFloat(doc, m["uv_index"]); err != nil {
+			return WeatherInfo{}, err
+		}
+	}
+	if info.Description, err = customString(doc, m["description"]); err != nil {
+		return WeatherInfo{}, err
+	}
+	if info.Type, err = customType(doc, m["type"], info.Description, m); err != nil {
+		return WeatherInfo{}, err
+	}
+
+	if config.Forecast == 0 || m["forecast"] == "" {
+		return info, nil
+	}
+	v, err := lookupPath(doc, m["forecast"])
+	if err != nil {
+		return WeatherInfo{}, fmt.Errorf("custom.forecast: %w", err)
+	}
+	days, ok := v.([]any)
+	if !ok {
+		return WeatherInfo{}, fmt.Errorf("custom.forecast: %q is not an array", m["forecast"])
+	}
+	for i, day := range days {
+		if i >= config.Forecast {
+			break
+		}
+		fd, err := customForecastDay(day, m)
+		if err != nil {
+			return WeatherInfo{}, fmt.Errorf("custom.forecast[%d]: %w", i, err)
+		}
+		info.Forecast = append(info.Forecast, fd)
+	}
+	return info, nil
+}
+
+func customForecastDay(doc any, m map[string]string) (ForecastDay, error) {
+	var fd ForecastDay
+	if p := m["forecast.date"]; p != "" {
+		v, err := lookupPath(doc, p)
+		if err != nil {
+			return ForecastDay{}, err
+		}
+		if fd.Date, err = customDate(v); err != nil {
+			return ForecastDay{}, err
+		}
+	}
+	var err error
+	if fd.MinTempC, fd.MinTempF, err = customTemps(doc, m["forecast.min_temp_c"], m["forecast.min_temp_f"]); err != nil {
+		return ForecastDay{}, err
+	}
+	if fd.MaxTempC, fd.MaxTempF, err = customTemps(doc, m["forecast.max_temp_c"], m["forecast.max_temp_f"]); err != nil {
+		return ForecastDay{}, err
+	}
+	if fd.Description, err = customString(doc, m["forecast.description"]); err != nil {
+		return ForecastDay{}, err
+	}
+	if fd.Type, err = customType(doc, m["forecast.type"], fd.Description, m); err != nil {
+		return ForecastDay{}, err
+	}
+	return fd, nil
+}
+
+// customTemps reads whichever of the Celsius and Fahrenheit paths are set
+// and derives the other. With neither set both are zero.
+func customTemps(doc any, pathC, pathF string) (float64, float64, error) {
+	switch {
+	case pathC != "" && pathF != "":
+		c, err := customFloat(doc, pathC)
+		if err != nil {
+			return 0, 0, err
+		}
+		f, err := customFloat(doc, pathF)
+		return c, f, err
+	case pathC != "":
+		c, err := customFloat(doc, pathC)
+		return c, celsiusToFahrenheit(c), err
+	case pathF != "":
+		f, err := customFloat(doc, pathF)
+		return (f - 32) * 5 / 9, f, err
+	}
+	return 0, 0, nil
+}
+
+func customFloat(doc any, path string) (float64, error) {
+	v, err := lookupPath(doc, path)
+	if err != nil {
+		return 0, err
+	}
+	switch x := v.(type) {
+	case float64:
+		return x, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %q is not a number", path, x)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("%s: expected a number, got %T", path, v)
+}
+
+// customString returns the value at path as text; an empty path yields "".
+func customString(doc any, path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	v, err := lookupPath(doc, path)
+	if err != nil {
+		return "", err
+	}
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("%s: expected a string, got %T", path, v)
+}
+
+// customType maps the value at path through the custom.type.<value> table.
+// Values without an entry, and reports without a type path, fall back to
+// classifying the description.
+func customType(doc any, path, desc string, m map[string]string) (WeatherType, error) {
+	if path == "" {
+		return ClassifyWeather(desc), nil
+	}
+	raw, err := customString(doc, path)
+	if err != nil {
+		return Unknown, err
+	}
+	if name, ok := m["type."+raw]; ok {
+		return weatherTypeNames[strings.ToLower(name)], nil
+	}
+	if wt := ClassifyWeather(raw); wt != Unknown {
+		return wt, nil
+	}
+	return ClassifyWeather(desc), nil
+}
+
+// customDate accepts a YYYY-MM-DD date, an RFC 3339 timestamp or Unix
+// seconds.
+func customDate(v any) (time.Time, error) {
+	switch x := v.(type) {
+	case float64:
+		t := time.Unix(int64(x), 0).UTC()
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	case string:
+		if d, err := time.Parse("2006-01-02", x); err == nil {
+			return d, nil
+		}
+		t, err := time.Parse(time.RFC3339, x)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unrecognized date %q", x)
+		}
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %v", v)
+}
+
+// lookupPath walks a decoded JSON document along a JSONPath-style path such
+// as "$.current.temp", "daily[0].max" or "data.list[2].main.temp". The
+// leading "$" and "$." are optional.
+func lookupPath(doc any, path string) (any, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	rest = strings.TrimPrefix(rest, ".")
+	cur := doc
+	for rest != "" {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%s: unclosed [", path)
+			}
+			idx, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad index %q", path, rest[1:end])
+			}
+			arr, ok := cur.([]any)
+			if !ok || idx < 0 || idx >= len(arr) {
+				return nil, fmt.Errorf("%s: index %d out of range", path, idx)
+			}
+			cur = arr[idx]
+			rest = strings.TrimPrefix(rest[end+1:], ".")
+			continue
+		}
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		key := rest[:end]
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %q is not an object", path, key)
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("%s: no field %q", path, key)
+		}
+		rest = strings.TrimPrefix(rest[end:], ".")
+	}
+	return cur, nil
+}
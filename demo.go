@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderDemo = "demo"
+	// demoPrefix selects a scenario through the city, e.g. -city=demo:stormy.
+	demoPrefix = "demo:"
+)
+
+// demoScenario seeds the current conditions of a demo report. Forecast days
+// drift away from it.
+type demoScenario struct {
+	Type  WeatherType
+	TempC float64
+	UV    float64
+	Desc  string
+}
+
+var demoScenarios = map[string]demoScenario{
+	"sunny":       {Sunny, 27, 8, "Sunny"},
+	"cloudy":      {Cloudy, 14, 2, "Overcast"},
+	"rainy":       {Rainy, 11, 1, "Moderate rain"},
+	"snowy":       {Snowy, -4, 1, "Heavy snow"},
+	"stormy":      {Stormy, 19, 0, "Thundery outbreaks in nearby"},
+	"foggy":       {Foggy, 6, 0, "Freezing fog"},
+	"clear-night": {ClearNight, 9, 0, "Clear"},
+	"unknown":     {Unknown, 15, 3, "Volcanic ash"},
+	"heatwave":    {Sunny, 49.5, 12, "Scorching sun"},
+	"arctic":      {Snowy, -52.3, 0, "Blowing snow"},
+	"long":        {Rainy, 12, 1, "Patchy light rain in area with thunder and a chance of hail later in the evening"},
+}
+
+var demoDescriptions = map[WeatherType][]string{
+	Sunny:   {"Sunny", "Clear", "Mostly sunny"},
+	Cloudy:  {"Partly cloudy", "Overcast", "Cloudy"},
+	Rainy:   {"Light rain", "Rain showers", "Moderate rain"},
+	Snowy:   {"Light snow", "Snow showers", "Heavy snow"},
+	Stormy:  {"Thunderstorm", "Thundery outbreaks possible", "Severe thunderstorms"},
+	Foggy:   {"Fog", "Mist", "Freezing fog"},
+	Unknown: {"Volcanic ash", "Dust haze", "Smoke"},
+}
+
+// demoAlerts are the warnings some scenarios come with; they run from the
+// start of the current hour for the given duration.
+var demoAlerts = map[string]struct {
+	Event, Severity string
+	For             time.Duration
+	Description     string
+}{
+	"stormy":   {"Severe Thunderstorm Warning", "Severe", 3 * time.Hour, "Thunderstorms with large hail and wind gusts up to 100 km/h."},
+	"snowy":    {"Winter Weather Advisory", "Moderate", 12 * time.Hour, "Snow accumulations of 10 to 15 cm expected. Travel could be difficult."},
+	"heatwave": {"Extreme Heat Warning", "Extreme", 48 * time.Hour, "Dangerously hot conditions. Stay indoors in air conditioning and drink plenty of water."},
+	"arctic":   {"Extreme Cold Warning", "Extreme", 24 * time.Hour, "Wind chills as low as -60 °C could cause frostbite on exposed skin in minutes."},
+}
+
+// demoForecastTypes are the types a forecast day may drift to. ClearNight
+// describes a moment rather than a day, so it is left out.
+var demoForecastTypes = []WeatherType{Sunny, Cloudy, Rainy, Snowy, Stormy, Foggy, Unknown}
+
+// demoProvider generates reports without a network. The same city always
+// yields the same numbers, so screenshots are reproducible.
+type demoProvider struct{}
+
+func init() {
+	RegisterProvider(demoProvider{})
+}
+
+func (demoProvider) Name() string { return ProviderDemo }
+
+func (demoProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 14, MaxHourlyHours: 48, Local: true, Alerts: true}
+}
+
+func (demoProvider) Validate(config Config) error {
+	_, _, err := demoScenarioFor(config.City)
+	return err
+}
+
+func (demoProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	return nil, errors.New("the demo provider generates reports locally")
+}
+
+func (demoProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+func (p demoProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	return p.Fetch(context.Background(), config)
+}
+
+func (demoProvider) Fetch(ctx context.Context, config Config) (WeatherInfo, error) {
+	name, sc, err := demoScenarioFor(config.City)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(config.City)))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	info := WeatherInfo{
+		TempC:       sc.TempC,
+		TempF:       celsiusToFahrenheit(sc.TempC),
+		UVIndex:     sc.UV,
+		Description: sc.Desc,
+		Type:        sc.Type,
+		Details:     demoDetails(sc, h.Sum64()),
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 0; i < config.Forecast && i < 14; i++ {
+		wt := sc.Type
+		if wt == ClearNight {
+			wt = Sunny
+		}
+		if i > 0 && rng.Float64() < 0.4 {
+			wt = demoForecastTypes[rng.Intn(len(demoForecastTypes))]
+		}
+		desc := sc.Desc
+		if i > 0 || wt != sc.Type {
+			pool := demoDescriptions[wt]
+			desc = pool[rng.Intn(len(pool))]
+		}
+		if name == "long" {
+			desc += " with gusts of wind and occasional sleet near higher ground"
+		}
+		maxC := round1(sc.TempC + (rng.Float64()*2-1)*4)
+		minC := round1(maxC - 3 - rng.Float64()*7)
+		info.Forecast = append(info.Forecast, ForecastDay{
+			Date:        today.AddDate(0, 0, i),
+			MinTempC:    minC,
+			MaxTempC:    maxC,
+			MinTempF:    celsiusToFahrenheit(minC),
+			MaxTempF:    celsiusToFahrenheit(maxC),
+			Description: desc,
+			Type:        wt,
+			Details:     demoDayDetails(wt, i, h.Sum64()),
+		})
+	}
+	info.Hourly = demoHourly(sc, min(config.Hourly, 48), now, h.Sum64())
+	if a, ok := demoAlerts[name]; ok && !config.NoAlerts {
+		onset := now.Truncate(time.Hour)
+		expires := onset.Add(a.For)
+		info.Alerts = []Alert{{
+			Headline:    a.Event + " for " + config.City,
+			Event:       a.Event,
+			Severity:    a.Severity,
+			Urgency:     "Immediate",
+			Area:        config.City,
+			Onset:       &onset,
+			Expires:     &expires,
+			Description: a.Description,
+			Source:      ProviderDemo,
+		}}
+	}
+	return info, nil
+}
+
+// demoHourly follows the scenario through a day: coolest before dawn,
+// warmest mid-afternoon, with rain chances to match the type.
+func demoHourly(sc demoScenario, hours int, now time.Time, seed uint64) []ForecastHour {
+	rng := rand.New(rand.NewSource(int64(seed >> 3)))
+	start := now.Truncate(time.Hour)
+	var out []ForecastHour
+	for i := 0; i < hours; i++ {
+		t := start.Add(time.Duration(i) * time.Hour)
+		tempC := round1(sc.TempC + 4*math.Sin(float64(t.Hour()-9)*math.Pi/12) + rng.Float64() - 0.5)
+		rain, snow := rng.Intn(10), 0
+		switch sc.Type {
+		case Rainy, Stormy:
+			rain = 40 + rng.Intn(60)
+		case Snowy:
+			snow = 40 + rng.Intn(60)
+		case Cloudy, Foggy:
+			rain = 5 + rng.Intn(30)
+		}
+		wind := round1(5 + rng.Float64()*25)
+		desc := sc.Desc
+		if rng.Float64() < 0.3 {
+			pool := demoDescriptions[sc.Type]
+			if len(pool) > 0 {
+				desc = pool[rng.Intn(len(pool))]
+			}
+		}
+		out = append(out, ForecastHour{
+			Time:         t,
+			TempC:        tempC,
+			TempF:        celsiusToFahrenheit(tempC),
+			Description:  desc,
+			Type:         sc.Type,
+			ChanceOfRain: rain,
+			ChanceOfSnow: snow,
+			WindKph:      wind,
+			WindMph:      round1(wind * 0.621371),
+			WindDir:      compassPoint(rng.Intn(360)),
+		})
+	}
+	return out
+}
+
+// demoDetails derives plausible current conditions from the scenario. It
+// draws from its own generator so the forecast stays as it was.
+func demoDetails(sc demoScenario, seed uint64) *Details {
+	rng := rand.New(rand.NewSource(int64(seed >> 1)))
+	wind := round1(5 + rng.Float64()*25)
+	humidity, pressure, vis, cloud, precip := 60, 1015.0, 10.0, 40, 0.0
+	switch sc.Type {
+	case Sunny, ClearNight:
+		humidity, cloud = 40, 5
+	case Cloudy:
+		cloud = 90
+	case Rainy:
+		humidity, pressure, vis, cloud, precip = 88, 1004, 6, 85, 1.2
+	case Snowy:
+		humidity, pressure, vis, cloud, precip = 85, 1008, 2, 95, 0.8
+	case Stormy:
+		wind += 30
+		humidity, pressure, vis, cloud, precip = 80, 992, 5, 90, 4.5
+	case Foggy:
+		humidity, vis, cloud = 98, 0.3, 100
+	}
+	pressure += math.Round((rng.Float64()*2 - 1) * 5)
+
+	feels := sc.TempC
+	switch {
+	case sc.TempC < 10:
+		feels -= wind / 8
+	case sc.TempC > 26:
+		feels += float64(humidity-30) / 8
+	}
+	feels = round1(feels)
+	gust := round1(wind * 1.5)
+	dir := rng.Intn(360)
+	return &Details{
+		FeelsLikeC:      feels,
+		FeelsLikeF:      celsiusToFahrenheit(feels),
+		WindKph:         wind,
+		WindMph:         round1(wind * 0.621371),
+		GustKph:         gust,
+		GustMph:         round1(gust * 0.621371),
+		WindDirDeg:      dir,
+		WindDir:         compassPoint(dir),
+		Humidity:        humidity,
+		PressureMb:      pressure,
+		PressureIn:      math.Round(pressure*0.02953*100) / 100,
+		VisibilityKm:    vis,
+		VisibilityMiles: round1(vis * 0.621371),
+		CloudCover:      cloud,
+		PrecipMM:        precip,
+		PrecipIn:        math.Round(precip/25.4*100) / 100,
+	}
+}
+
+// demoDayDetails fills in the extras for forecast day i, again from a
+// generator of its own.
+func demoDayDetails(wt WeatherType, i int, seed uint64) *DayDetails {
+	rng := rand.New(rand.NewSource(int64(seed>>2) + int64(i)))
+	rain, snow, precip := rng.Intn(15), 0, 0.0
+	switch wt {
+	case Rainy:
+		rain, precip = 60+rng.Intn(35), round1(2+rng.Float64()*10)
+	case Stormy:
+		rain, precip = 70+rng.Intn(30), round1(8+rng.Float64()*20)
+	case Snowy:
+		rain, snow, precip = rng.Intn(20), 60+rng.Intn(35), round1(1+rng.Float64()*6)
+	case Cloudy, Foggy:
+		rain = 10 + rng.Intn(25)
+	}
+	wind := round1(8 + rng.Float64()*30)
+	if wt == Stormy {
+		wind += 25
+	}
+	sunrise := 6*60 + 20 + rng.Intn(20)
+	sunset := 19*60 + 30 + rng.Intn(20)
+	moonrise := (13*60 + i*50) % (24 * 60)
+	return &DayDetails{
+		ChanceOfRain: rain,
+		ChanceOfSnow: snow,
+		PrecipMM:     precip,
+		PrecipIn:     math.Round(precip/25.4*100) / 100,
+		MaxWindKph:   wind,
+		MaxWindMph:   round1(wind * 0.621371),
+		AvgHumidity:  50 + rng.Intn(45),
+		UVIndex:      float64(rng.Intn(9)),
+		Sunrise:      fmt.Sprintf("%02d:%02d", sunrise/60, sunrise%60),
+		Sunset:       fmt.Sprintf("%02d:%02d", sunset/60, sunset%60),
+		Moonrise:     fmt.Sprintf("%02d:%02d", moonrise/60, moonrise%60),
+	}
+}
+
+// demoScenarioFor picks the scenario named after "demo:" in city. Any other
+// city maps to a fixed scenario chosen by its hash.
+func demoScenarioFor(city string) (string, demoScenario, error) {
+	names := make([]string, 0, len(demoScenarios))
+	for name := range demoScenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if name, ok := strings.CutPrefix(strings.ToLower(city), demoPrefix); ok {
+		sc, ok := demoScenarios[name]
+		if !ok {
+			return "", demoScenario{}, fmt.Errorf("unknown demo scenario %q (want one of: %s)", name, strings.Join(names, ", "))
+		}
+		return name, sc, nil
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(city)))
+	name := names[h.Sum32()%uint32(len(names))]
+	return name, demoScenarios[name], nil
+}
+
+// isDemoCity reports whether city names a demo scenario.
+func isDemoCity(city string) bool {
+	return strings.HasPrefix(strings.ToLower(city), demoPrefix)
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// execPrefix marks a provider name as an external program, e.g.
+// apiProvider=exec:/usr/local/bin/wrep-acme.
+const execPrefix = "exec:"
+
+// execRequest is written to a plugin's stdin.
+type execRequest struct {
+	Protocol     int    `json:"protocol"`
+	City         string `json:"city"`
+	Units        string `json:"units"`
+	ForecastDays int    `json:"forecast_days"`
+}
+
+// execExtras picks up what WeatherInfo alone cannot tell from a plugin's
+// output: the optional "type" names, and which temperature units were sent.
+type execExtras struct {
+	Type     string   `json:"type"`
+	TempC    *float64 `json:"temp_c"`
+	TempF    *float64 `json:"temp_f"`
+	Forecast []struct {
+		Type     string   `json:"type"`
+		MinTempC *float64 `json:"min_temp_c"`
+		MaxTempC *float64 `json:"max_temp_c"`
+		MinTempF *float64 `json:"min_temp_f"`
+		MaxTempF *float64 `json:"max_temp_f"`
+	} `json:"forecast"`
+}
+
+// execProvider runs an external program for each fetch. It is not
+// registered; LookupProvider creates one for any exec: name.
+type execProvider struct {
+	path string
+}
+
+func (p execProvider) Name() string { return execPrefix + p.path }
+
+func (execProvider) Capabilities() Capabilities {
+	return Capabilities{}
+}
+
+func (p execProvider) Validate(config Config) error {
+	if p.path == "" {
+		return errors.New("apiProvider=exec: needs a program path (e.g. exec:/usr/local/bin/my-plugin)")
+	}
+	if _, err := exec.LookPath(p.path); err != nil {
+		return fmt.Errorf("apiProvider=%s: %w", p.Name(), err)
+	}
+	return nil
+}
+
+func (execProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	return nil, errors.New("exec providers run a program instead of sending a request")
+}
+
+func (execProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+// Parse decodes a plugin's stdout. A missing temperature unit is converted
+// from the other, and types the plugin leaves out are classified from the
+// descriptions.
+func (execProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var info WeatherInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode plugin output: %w", err)
+	}
+	var extras execExtras
+	_ = json.Unmarshal(body, &extras)
+
+	info.TempC, info.TempF = fillTemp(extras.TempC, extras.TempF)
+	info.Type = execWeatherType(extras.Type, info.Description)
+	for i := range info.Forecast {
+		d := &info.Forecast[i]
+		x := extras.Forecast[i]
+		d.MinTempC, d.MinTempF = fillTemp(x.MinTempC, x.MinTempF)
+		d.MaxTempC, d.MaxTempF = fillTemp(x.MaxTempC, x.MaxTempF)
+		d.Type = execWeatherType(x.Type, d.Description)
+	}
+	if config.Forecast < len(info.Forecast) {
+		info.Forecast = info.Forecast[:config.Forecast]
+	}
+	return info, nil
+}
+
+func (p execProvider) Fetch(ctx context.Context, config Config) (WeatherInfo, error) {
+	req, err := json.Marshal(execRequest{
+		Protocol:     1,
+		City:         config.City,
+		Units:        config.Unit,
+		ForecastDays: config.Forecast,
+	})
+	if err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	// A plugin run gets the same budget as an HTTP request.
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Stdin = bytes.NewReader(req)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if config.Verbose && !config.Quiet {
+		fmt.Fprintln(os.Stderr, "Running:", p.path)
+	}
+	err = cmd.Run()
+	msg := strings.TrimSpace(stderr.String())
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return WeatherInfo{}, fmt.Errorf("plugin %s timed out after %s", p.path, timeout)
+	}
+	if err != nil {
+		if msg != "" {
+			return WeatherInfo{}, fmt.Errorf("plugin %s failed: %w: %s", p.path, err, msg)
+		}
+		return WeatherInfo{}, fmt.Errorf("plugin %s failed: %w", p.path, err)
+	}
+	if msg != "" && config.Verbose && !config.Quiet {
+		fmt.Fprintf(os.Stderr, "wrep: %s: %s\n", p.path, msg)
+	}
+
+	info, err := p.Parse(stdout.Bytes(), config)
+	if err != nil && msg != "" {
+		return WeatherInfo{}, fmt.Errorf("%w (plugin stderr: %s)", err, msg)
+	}
+	return info, err
+}
+
+// fillTemp returns both units when the plugin sent at least one of them.
+func fillTemp(c, f *float64) (float64, float64) {
+	switch {
+	case c != nil && f != nil:
+		return *c, *f
+	case c != nil:
+		return *c, celsiusToFahrenheit(*c)
+	case f != nil:
+		return (*f - 32) * 5 / 9, *f
+	}
+	return 0, 0
+}
+
+// execWeatherType maps a plugin-supplied type name, falling back to the
+// description when the name is missing or unrecognized.
+func execWeatherType(name, desc string) WeatherType {
+	if wt, ok := weatherTypeNames[strings.ToLower(name)]; ok {
+		return wt
+	}
+	return ClassifyWeather(desc)
+}
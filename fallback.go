@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	healthBaseCooldown = 30 * time.Second
+	healthMaxCooldown  = 10 * time.Minute
+)
+
+// providerHealth tracks consecutive failures of one provider. After a
+// failure the provider is skipped for a cooldown that doubles with each
+// further failure, so live mode doesn't sit through a timeout on every tick
+// while wttr.in is overloaded.
+type providerHealth struct {
+	failures  int
+	lastError error
+	until     time.Time
+}
+
+var (
+	healthMu sync.Mutex
+	health   = map[string]*providerHealth{}
+)
+
+func providerCoolingDown(name string) (time.Time, bool) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	h, ok := health[name]
+	if !ok || !time.Now().Before(h.until) {
+		return time.Time{}, false
+	}
+	return h.until, true
+}
+
+func recordProviderResult(name string, err error) {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	if err == nil {
+		delete(health, name)
+		return
+	}
+	h, ok := health[name]
+	if !ok {
+		h = &providerHealth{}
+		health[name] = h
+	}
+	h.failures++
+	h.lastError = err
+	cooldown := healthBaseCooldown << (h.failures - 1)
+	if cooldown > healthMaxCooldown || cooldown <= 0 {
+		cooldown = healthMaxCooldown
+	}
+	h.until = time.Now().Add(cooldown)
+}
+
+// providersFailing reports whether the most recent fetch from every named
+// provider failed.
+func providersFailing(names []string) bool {
+	healthMu.Lock()
+	defer healthMu.Unlock()
+	for _, name := range names {
+		if h, ok := health[name]; !ok || h.failures == 0 {
+			return false
+		}
+	}
+	return len(names) > 0
+}
+
+// FetchWeather returns a report for config, reusing the on-disk cache while
+// it is younger than config.CacheTTL. When every provider fails, the last
+// cached report is returned instead, marked as stale.
+func FetchWeather(ctx context.Context, config Config) (WeatherInfo, error) {
+	key, cacheable := responseCacheKey(config)
+	if !cacheable {
+		return fetchWithAlerts(ctx, config)
+	}
+	verbose := config.Verbose && !config.Quiet
+
+	cached, haveCached := loadCachedResponse(key)
+	if haveCached && config.CacheTTL > 0 && time.Since(cached.Fetched) < config.CacheTTL {
+		if verbose {
+			fmt.Fprintf(os.Stderr, "wrep: using cached report from %s ago\n", formatAge(time.Since(cached.Fetched)))
+		}
+		return cached.Info, nil
+	}
+
+	info, err := fetchWithAlerts(ctx, config)
+	if err == nil {
+		// A report missing its alerts must not stand in for a complete
+		// one until the TTL runs out.
+		if info.AlertsFailed {
+			return info, nil
+		}
+		if err := storeCachedResponse(key, info); err != nil && verbose {
+			fmt.Fprintln(os.Stderr, "wrep:", err)
+		}
+		return info, nil
+	}
+	if !haveCached || ctx.Err() != nil {
+		return WeatherInfo{}, err
+	}
+	stale := cached.Info
+	stale.Stale = &Staleness{
+		FetchedAt:  cached.Fetched,
+		AgeSeconds: int64(time.Since(cached.Fetched).Seconds()),
+		Error:      err.Error(),
+	}
+	if !config.Quiet {
+		fmt.Fprintln(os.Stderr, "wrep:", err)
+	}
+	return stale, nil
+}
+
+// fetchWithAlerts runs the chain and adds MeteoAlarm warnings, which come
+// from a feed of their own rather than from the provider.
+func fetchWithAlerts(ctx context.Context, config Config) (WeatherInfo, error) {
+	info, err := fetchChain(ctx, config)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	if err := addMeteoAlarm(ctx, config, &info); err != nil {
+		return WeatherInfo{}, err
+	}
+	return info, nil
+}
+
+// fetchChain tries each provider of config.APIProviders in order and
+// returns the first report that succeeds, tagged with the provider that
+// answered. Providers in cooldown are skipped unless nothing else is left.
+func fetchChain(ctx context.Context, config Config) (WeatherInfo, error) {
+	chain := config.APIProviders
+	if len(chain) == 0 {
+		chain = []string{config.APIProvider}
+	}
+	verbose := config.Verbose && !config.Quiet
+
+	var errs []error
+	var skipped []string
+	try := func(name string) (WeatherInfo, bool) {
+		p, ok := LookupProvider(name)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown provider", name))
+			return WeatherInfo{}, false
+		}
+		cfg := config
+		cfg.APIProvider = name
+		info, err := fetchFrom(ctx, p, cfg)
+		if ctx.Err() != nil {
+			// Cancelled by the user, not the provider's fault.
+			errs = append(errs, ctx.Err())
+			return WeatherInfo{}, false
+		}
+		recordProviderResult(name, err)
+		if err != nil {
+			if verbose && len(chain) > 1 {
+				fmt.Fprintf(os.Stderr, "wrep: %s failed: %v\n", name, err)
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return WeatherInfo{}, false
+		}
+		info.Provider = name
+		if verbose {
+			fmt.Fprintln(os.Stderr, "wrep: answered by", name)
+		}
+		return info, true
+	}
+
+	for _, name := range chain {
+		if until, cooling := providerCoolingDown(name); cooling && len(chain) > 1 {
+			if verbose {
+				fmt.Fprintf(os.Stderr, "wrep: skipping %s until %s after recent failures\n", name, until.Local().Format(time.Kitchen))
+			}
+			skipped = append(skipped, name)
+			continue
+		}
+		if info, ok := try(name); ok {
+			return info, nil
+		}
+		if ctx.Err() != nil {
+			return WeatherInfo{}, ctx.Err()
+		}
+	}
+	for _, name := range skipped {
+		if info, ok := try(name); ok {
+			return info, nil
+		}
+		if ctx.Err() != nil {
+			return WeatherInfo{}, ctx.Err()
+		}
+	}
+
+	if len(chain) == 1 && len(errs) == 1 {
+		if inner := errors.Unwrap(errs[0]); inner != nil {
+			return WeatherInfo{}, inner
+		}
+		return WeatherInfo{}, errs[0]
+	}
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return WeatherInfo{}, fmt.Errorf("all providers failed: %s", strings.Join(msgs, "; "))
+}
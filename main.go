@@ -13,8 +13,11 @@ import (
 
 var version = "dev"
 
+// exitInterrupted is the conventional status for a process stopped by SIGINT.
+const exitInterrupted = 130
+
 func main() {
-	UserAgent = "wrep/" + resolveVersion()
+	UserAgent = "wrep/" + resolveVersion() + " (+https://github.com/TheOddKn1ght/wrep)"
 
 	config, err := GetConfig()
 	if err != nil {
@@ -27,61 +30,119 @@ func main() {
 		return
 	}
 
-	if config.APIProvider == ProviderWttr && config.Forecast > 3 && !config.Quiet {
-		fmt.Fprintln(os.Stderr, "wrep: wttr.in returns at most 3 days; truncating")
+	// fetchFrom trims the request to each provider's limits.
+	if !config.Quiet {
+		warnCapabilities(os.Stderr, config)
+	}
+
+	if err := configureHTTP(config); err != nil {
+		fmt.Fprintln(os.Stderr, "wrep:", err)
+		os.Exit(1)
+	}
+	if err := installRecording(config); err != nil {
+		fmt.Fprintln(os.Stderr, "wrep:", err)
+		os.Exit(1)
+	}
+
+	// Ctrl+C cancels whatever is in flight. Once it has, signals go back to
+	// their default handling so a second Ctrl+C kills wrep outright.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if config.Command == CommandStation {
+		if err := runStation(ctx, config); err != nil {
+			fmt.Fprintln(os.Stderr, "wrep:", err)
+			os.Exit(1)
+		}
+		return
 	}
 
 	if config.Live {
-		if err := runLive(config, os.Stdout); err != nil {
+		if err := runLive(ctx, config, os.Stdout); err != nil {
 			fmt.Fprintln(os.Stderr, "wrep:", err)
 			os.Exit(1)
 		}
 		return
 	}
 
-	if err := runOnce(config, os.Stdout); err != nil {
+	if err := runOnce(ctx, config, os.Stdout); err != nil {
+		if ctx.Err() != nil {
+			os.Exit(exitInterrupted)
+		}
 		fmt.Fprintln(os.Stderr, "wrep:", err)
 		os.Exit(1)
 	}
 }
 
-func runOnce(cfg Config, out io.Writer) error {
-	info, err := FetchWeather(cfg)
+func runOnce(ctx context.Context, cfg Config, out io.Writer) error {
+	if cfg.Command == CommandCompare {
+		return runCompare(ctx, cfg, out)
+	}
+	if cfg.Command == CommandNowcast {
+		return runNowcast(ctx, cfg, out)
+	}
+	if len(cfg.Cities) > 1 {
+		return runMultiCity(ctx, cfg, out)
+	}
+	fetch := FetchWeather
+	if cfg.Consensus {
+		fetch = FetchConsensus
+	}
+	info, err := fetch(ctx, cfg)
 	if err != nil {
 		return err
 	}
+	if cfg.AlertsOnly {
+		return renderAlertsOnly(out, info, cfg)
+	}
 	Display(out, info, cfg)
 	return nil
 }
 
-func runLive(cfg Config, out io.Writer) error {
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-
+func runLive(ctx context.Context, cfg Config, out io.Writer) error {
 	clearScreen := stdoutIsTTY() && !cfg.JSON && !cfg.Quiet
+	names := cfg.APIProviders
+	if cfg.Consensus || cfg.Command == CommandCompare {
+		names = pollProviders(cfg)
+	}
 
-	tick := func() {
+	// failures counts consecutive ticks on which no provider answered; the
+	// interval stretches with it so a struggling provider isn't hammered.
+	failures := 0
+	tick := func() time.Duration {
 		if clearScreen {
 			fmt.Fprint(out, "\033[H\033[2J")
 		} else if !cfg.JSON {
 			fmt.Fprintf(out, "--- %s ---\n", time.Now().Format(time.RFC3339))
 		}
-		if err := runOnce(cfg, out); err != nil {
+		err := runOnce(ctx, cfg, out)
+		if err != nil && ctx.Err() == nil {
 			fmt.Fprintln(os.Stderr, "wrep:", err)
 		}
+		if err != nil || providersFailing(names) {
+			failures++
+		} else {
+			failures = 0
+		}
+		delay := liveDelay(cfg.Interval, failures)
+		if delay != cfg.Interval && cfg.Verbose && !cfg.Quiet {
+			fmt.Fprintf(os.Stderr, "wrep: providers failing; next refresh in %s\n", delay)
+		}
+		return delay
 	}
 
-	tick()
-
-	ticker := time.NewTicker(cfg.Interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(tick())
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		case <-ticker.C:
-			tick()
+		case <-timer.C:
+			timer.Reset(tick())
 		}
 	}
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const ProviderMETAR = "metar"
+
+type metarProvider struct{}
+
+func init() {
+	RegisterProvider(metarProvider{})
+}
+
+func (metarProvider) Name() string { return ProviderMETAR }
+
+// TAFs cover 24 to 30 hours, so at most two calendar days.
+func (metarProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 2}
+}
+
+// Validate accepts a 4-letter ICAO station code as the city, or any city
+// when reports come from -metar-file.
+func (metarProvider) Validate(config Config) error {
+	if config.MetarFile != "" || reStation.MatchString(strings.ToUpper(strings.TrimSpace(config.City))) {
+		return nil
+	}
+	return fmt.Errorf("the metar provider needs a 4-letter ICAO station code as -city (got %q)", config.City)
+}
+
+func (metarProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	return aviationWeatherRequest(ctx, "metar", config)
+}
+
+func (metarProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+// Parse accepts raw report text: a METAR, optionally followed by a TAF.
+func (metarProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	metar, taf := splitReports(string(body))
+	if metar == "" {
+		if config.MetarFile == "" {
+			return WeatherInfo{}, fmt.Errorf("no METAR available for station %q", strings.ToUpper(config.City))
+		}
+		return WeatherInfo{}, errors.New("no METAR found in input")
+	}
+	return decodeAviation(metar, taf, config, time.Now())
+}
+
+// Fetch reads reports from -metar-file when one is given and otherwise asks
+// aviationweather.gov for the latest METAR and, for -f, the current TAF.
+func (p metarProvider) Fetch(ctx context.Context, config Config) (WeatherInfo, error) {
+	if config.MetarFile != "" {
+		text, err := readMetarFile(config.MetarFile)
+		if err != nil {
+			return WeatherInfo{}, err
+		}
+		return p.Parse(text, config)
+	}
+
+	req, err := p.BuildRequest(ctx, config)
+	if err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to build request: %w", err)
+	}
+	body, err := fetchBody(req, config, p.CheckStatus)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	if config.Forecast > 0 {
+		req, err := aviationWeatherRequest(ctx, "taf", config)
+		if err != nil {
+			return WeatherInfo{}, fmt.Errorf("failed to build request: %w", err)
+		}
+		taf, err := fetchBody(req, config, p.CheckStatus)
+		if err != nil {
+			return WeatherInfo{}, err
+		}
+		body = append(append(body, '\n', '\n'), taf...)
+	}
+	return p.Parse(body, config)
+}
+
+func aviationWeatherRequest(ctx context.Context, product string, config Config) (*http.Request, error) {
+	station := strings.ToUpper(strings.TrimSpace(config.City))
+	if !reStation.MatchString(station) {
+		return nil, fmt.Errorf("the metar provider needs a 4-letter ICAO station code as -city (got %q)", config.City)
+	}
+	u, err := url.Parse(baseURL(config, ProviderMETAR) + "/" + product)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse aviationweather.gov URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("ids", station)
+	q.Set("format", "raw")
+	u.RawQuery = q.Encode()
+	return newRequest(ctx, u.String())
+}
+
+func readMetarFile(path string) ([]byte, error) {
+	if path == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reports from stdin: %w", err)
+		}
+		return b, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reports: %w", err)
+	}
+	return b, nil
+}
+
+// decodeAviation builds a WeatherInfo from raw METAR and TAF text. The TAF
+// groups starting within config.Forecast days go into Aviation.Periods;
+// the forecast gets one day per UTC calendar day the TAF covers, described
+// by the prevailing group at midday. TAFs rarely carry temperatures, so
+// lows and highs without a TN/TX group are marked missing.
+func decodeAviation(metar, taf string, config Config, ref time.Time) (WeatherInfo, error) {
+	av, temp, err := parseMETAR(metar, ref)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	info := WeatherInfo{
+		TempC:       temp,
+		TempF:       celsiusToFahrenheit(temp),
+		Description: describeConditions(av.FlightConditions),
+		Type:        conditionsWeatherType(av.FlightConditions),
+		Aviation:    &av,
+	}
+
+	if taf == "" || config.Forecast == 0 {
+		return info, nil
+	}
+	periods, temps, err := parseTAF(taf, ref)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	av.RawTAF = taf
+
+	first := periods[0].From.Truncate(24 * time.Hour)
+	limit := first.AddDate(0, 0, config.Forecast)
+	for _, p := range periods {
+		if !p.From.Before(limit) {
+			break
+		}
+		av.Periods = append(av.Periods, p)
+	}
+	// FM groups cut the base period short, so the validity ends with the
+	// latest group.
+	var end time.Time
+	for _, p := range periods {
+		if p.To.After(end) {
+			end = p.To
+		}
+	}
+	for day := first; day.Before(limit) && day.Before(end); day = day.AddDate(0, 0, 1) {
+		p := prevailingPeriod(av.Periods, day.Add(12*time.Hour))
+		fd := ForecastDay{
+			Date:        day,
+			Description: describeConditions(p.FlightConditions),
+			Type:        conditionsWeatherType(p.FlightConditions),
+			NoMin:       true,
+			NoMax:       true,
+		}
+		for _, t := range temps {
+			if t.Time.Before(day) || !t.Time.Before(day.AddDate(0, 0, 1)) {
+				continue
+			}
+			if t.Max {
+				fd.MaxTempC, fd.MaxTempF, fd.NoMax = t.C, celsiusToFahrenheit(t.C), false
+			} else {
+				fd.MinTempC, fd.MinTempF, fd.NoMin = t.C, celsiusToFahrenheit(t.C), false
+			}
+		}
+		info.Forecast = append(info.Forecast, fd)
+	}
+	return info, nil
+}
+
+// prevailingPeriod returns the last base, FM or BECMG group that started by
+// t, or the first group when none has. TEMPO and PROB groups are passing
+// deviations and never describe a day.
+func prevailingPeriod(periods []TAFPeriod, t time.Time) TAFPeriod {
+	p := periods[0]
+	for _, q := range periods[1:] {
+		if q.From.After(t) {
+			break
+		}
+		if q.Change == "BASE" || q.Change == "FM" || q.Change == "BECMG" {
+			p = q
+		}
+	}
+	return p
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FlightVFR  = "VFR"
+	FlightMVFR = "MVFR"
+	FlightIFR  = "IFR"
+	FlightLIFR = "LIFR"
+)
+
+// unlimitedVisM is what "9999", "CAVOK" and "P6SM" decode to.
+const unlimitedVisM = 10000
+
+const metersPerSM = 1609.344
+
+// Aviation is the decoded METAR (plus TAF, when one was read) behind a
+// report from the metar provider.
+type Aviation struct {
+	Station  string    `json:"station"`
+	Observed time.Time `json:"observed"`
+	Raw      string    `json:"raw_metar"`
+	RawTAF   string    `json:"raw_taf,omitempty"`
+	FlightConditions
+	Periods []TAFPeriod `json:"taf,omitempty"`
+}
+
+// FlightConditions are the elements shared by METAR observations and TAF
+// forecast groups.
+type FlightConditions struct {
+	WindDirDeg   int          `json:"wind_dir_deg"`
+	WindVariable bool         `json:"wind_variable,omitempty"`
+	WindKt       float64      `json:"wind_kt"`
+	GustKt       float64      `json:"gust_kt,omitempty"`
+	VisibilityM  float64      `json:"visibility_m"`
+	VisibilitySM float64      `json:"visibility_sm"`
+	CeilingFt    int          `json:"ceiling_ft,omitempty"`
+	Clouds       []CloudLayer `json:"clouds,omitempty"`
+	Weather      []string     `json:"weather,omitempty"`
+	Category     string       `json:"flight_category"`
+}
+
+type CloudLayer struct {
+	Cover  string `json:"cover"`
+	BaseFt int    `json:"base_ft"`
+	Type   string `json:"type,omitempty"`
+}
+
+// TAFPeriod is one group of a TAF: the base forecast or an FM, BECMG,
+// TEMPO or PROBnn change group, with the conditions in effect during it.
+type TAFPeriod struct {
+	Change string    `json:"change"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+	FlightConditions
+}
+
+var (
+	reStation  = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	reObsTime  = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	reWind     = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	reWindVar  = regexp.MustCompile(`^\d{3}V\d{3}$`)
+	reVisM     = regexp.MustCompile(`^(\d{4})(NDV)?$`)
+	reVisSM    = regexp.MustCompile(`^([PM])?(\d+)?(?:(\d)/(\d{1,2}))?SM$`)
+	reCloud    = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	reTemp     = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	reWeather  = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+	reValidity = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	reFM       = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	reProb     = regexp.MustCompile(`^PROB(\d{2})$`)
+	reTAFTemp  = regexp.MustCompile(`^T([XN])(M?\d{2})/(\d{2})(\d{2})Z$`)
+)
+
+// splitReports separates raw text holding METARs and TAFs (as served by
+// aviationweather.gov or pasted from a briefing) into the first METAR and
+// the first TAF. TAF continuation lines are indented or start with a change
+// group keyword.
+func splitReports(text string) (metar, taf string) {
+	var tafLines []string
+	inTAF := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			inTAF = false
+			continue
+		}
+		if strings.HasPrefix(trimmed, "TAF") {
+			if taf != "" || len(tafLines) > 0 {
+				inTAF = false
+				continue
+			}
+			inTAF = true
+			tafLines = append(tafLines, trimmed)
+			continue
+		}
+		continuation := line != trimmed || isChangeGroup(firstField(trimmed))
+		if inTAF && continuation {
+			tafLines = append(tafLines, trimmed)
+			continue
+		}
+		inTAF = false
+		if metar == "" {
+			metar = strings.TrimSuffix(trimmed, "=")
+		}
+	}
+	taf = strings.TrimSuffix(strings.Join(tafLines, " "), "=")
+	return metar, taf
+}
+
+func firstField(s string) string {
+	if f := strings.Fields(s); len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
+
+func isChangeGroup(tok string) bool {
+	return tok == "BECMG" || tok == "TEMPO" || reFM.MatchString(tok) || reProb.MatchString(tok)
+}
+
+// parseMETAR decodes a raw METAR or SPECI. ref anchors the day-of-month in
+// the report to a full date.
+func parseMETAR(raw string, ref time.Time) (Aviation, float64, error) {
+	tokens := strings.Fields(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	for len(tokens) > 0 && (tokens[0] == "METAR" || tokens[0] == "SPECI" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 2 || !reStation.MatchString(tokens[0]) {
+		return Aviation{}, 0, errors.New("METAR does not start with an ICAO station code")
+	}
+	av := Aviation{Station: tokens[0], Raw: strings.TrimSpace(raw)}
+	m := reObsTime.FindStringSubmatch(tokens[1])
+	if m == nil {
+		return Aviation{}, 0, fmt.Errorf("invalid METAR observation time %q", tokens[1])
+	}
+	av.Observed = resolveDayTime(ref, atoi(m[1]), atoi(m[2]), atoi(m[3]))
+
+	var temp float64
+	haveTemp := false
+	fc := &av.FlightConditions
+	rest := tokens[2:]
+	for i := 0; i < len(rest); i++ {
+		tok := rest[i]
+		if tok == "RMK" || tok == "NOSIG" || tok == "TEMPO" || tok == "BECMG" {
+			break
+		}
+		if mm := reTemp.FindStringSubmatch(tok); mm != nil {
+			temp = parseSignedTemp(mm[1])
+			haveTemp = true
+			continue
+		}
+		i += parseConditionToken(fc, rest, i)
+	}
+	if !haveTemp {
+		return Aviation{}, 0, errors.New("METAR has no temperature group")
+	}
+	finishConditions(fc)
+	return av, temp, nil
+}
+
+// parseConditionToken applies tokens[i] (and, for split visibilities like
+// "1 1/2SM", the token after it) to fc and returns how many extra tokens
+// were consumed.
+func parseConditionToken(fc *FlightConditions, tokens []string, i int) int {
+	tok := tokens[i]
+	switch {
+	case tok == "AUTO", tok == "COR", tok == "NIL":
+	case tok == "CAVOK":
+		fc.VisibilityM = unlimitedVisM
+		fc.Clouds = nil
+	case tok == "SKC", tok == "CLR", tok == "NSC", tok == "NCD":
+		fc.Clouds = nil
+	case tok == "NSW":
+		fc.Weather = nil
+	case reWind.MatchString(tok):
+		m := reWind.FindStringSubmatch(tok)
+		scale := windToKnots(m[4])
+		if m[1] == "VRB" {
+			fc.WindVariable = true
+			fc.WindDirDeg = 0
+		} else {
+			fc.WindVariable = false
+			fc.WindDirDeg = atoi(m[1])
+		}
+		fc.WindKt = math.Round(float64(atoi(m[2])) * scale)
+		fc.GustKt = 0
+		if m[3] != "" {
+			fc.GustKt = math.Round(float64(atoi(m[3])) * scale)
+		}
+	case reWindVar.MatchString(tok):
+	case reVisM.MatchString(tok):
+		m := reVisM.FindStringSubmatch(tok)
+		v := float64(atoi(m[1]))
+		if v >= 9999 {
+			v = unlimitedVisM
+		}
+		fc.VisibilityM = v
+	case reVisSM.MatchString(tok):
+		fc.VisibilityM = parseVisSM(tok, 0)
+	case i+1 < len(tokens) && isWholeNumber(tok) && reVisSM.MatchString(tokens[i+1]):
+		fc.VisibilityM = parseVisSM(tokens[i+1], float64(atoi(tok)))
+		return 1
+	case reCloud.MatchString(tok):
+		m := reCloud.FindStringSubmatch(tok)
+		layer := CloudLayer{Cover: m[1]}
+		if m[2] != "///" {
+			layer.BaseFt = atoi(m[2]) * 100
+		}
+		if m[3] != "///" {
+			layer.Type = m[3]
+		}
+		fc.Clouds = append(fc.Clouds, layer)
+	case strings.HasPrefix(tok, "R") && strings.Contains(tok, "/"):
+	case isWeatherToken(tok):
+		fc.Weather = append(fc.Weather, tok)
+	}
+	return 0
+}
+
+// parseTAF decodes a raw TAF into its periods. Elements a change group does
+// not mention carry over from the prevailing forecast, except weather after
+// FM; TEMPO and PROB groups never alter the prevailing conditions.
+func parseTAF(raw string, ref time.Time) ([]TAFPeriod, []tafTemp, error) {
+	tokens := strings.Fields(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	for len(tokens) > 0 && (tokens[0] == "TAF" || tokens[0] == "AMD" || tokens[0] == "COR") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) < 3 || !reStation.MatchString(tokens[0]) {
+		return nil, nil, errors.New("TAF does not start with an ICAO station code")
+	}
+	tokens = tokens[1:]
+	if reObsTime.MatchString(tokens[0]) {
+		tokens = tokens[1:]
+	}
+	from, to, ok := parseValidity(tokens[0], ref)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid TAF validity %q", tokens[0])
+	}
+	tokens = tokens[1:]
+
+	var periods []TAFPeriod
+	var temps []tafTemp
+	prevailing := FlightConditions{}
+	cur := TAFPeriod{Change: "BASE", From: from, To: to}
+	temporary := false
+
+	flush := func() {
+		if !temporary {
+			prevailing = cur.FlightConditions
+		}
+		fc := cur.FlightConditions
+		fc.Clouds = append([]CloudLayer(nil), fc.Clouds...)
+		fc.Weather = append([]string(nil), fc.Weather...)
+		finishConditions(&fc)
+		cur.FlightConditions = fc
+		periods = append(periods, cur)
+	}
+	start := func(change string, pFrom, pTo time.Time, temp bool) {
+		flush()
+		temporary = temp
+		cur = TAFPeriod{Change: change, From: pFrom, To: pTo, FlightConditions: prevailing}
+		cur.Clouds = append([]CloudLayer(nil), prevailing.Clouds...)
+		cur.Weather = append([]string(nil), prevailing.Weather...)
+	}
+	// A group that reports any clouds or weather replaces the inherited
+	// lists rather than appending to them.
+	cloudsSet, weatherSet := false, false
+
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case reFM.MatchString(tok):
+			m := reFM.FindStringSubmatch(tok)
+			start("FM", resolveDayTime(ref, atoi(m[1]), atoi(m[2]), atoi(m[3])), to, false)
+			// FM starts a complete new forecast; weather it leaves out
+			// has ended.
+			cur.Weather = nil
+			cloudsSet, weatherSet = false, false
+			continue
+		case tok == "BECMG", tok == "TEMPO", reProb.MatchString(tok):
+			change := tok
+			if i+1 < len(tokens) && tokens[i+1] == "TEMPO" {
+				change += " TEMPO"
+				i++
+			}
+			pFrom, pTo := cur.From, to
+			if i+1 < len(tokens) {
+				if f, t, ok := parseValidity(tokens[i+1], ref); ok {
+					pFrom, pTo = f, t
+					i++
+				}
+			}
+			start(change, pFrom, pTo, change != "BECMG")
+			cloudsSet, weatherSet = false, false
+			continue
+		case reTAFTemp.MatchString(tok):
+			m := reTAFTemp.FindStringSubmatch(tok)
+			temps = append(temps, tafTemp{
+				Max:  m[1] == "X",
+				C:    parseSignedTemp(m[2]),
+				Time: resolveDayTime(ref, atoi(m[3]), atoi(m[4]), 0),
+			})
+			continue
+		case tok == "RMK":
+			i = len(tokens)
+			continue
+		}
+
+		if reCloud.MatchString(tok) && !cloudsSet {
+			cur.Clouds = nil
+			cloudsSet = true
+		}
+		if isWeatherToken(tok) && !weatherSet {
+			cur.Weather = nil
+			weatherSet = true
+		}
+		i += parseConditionToken(&cur.FlightConditions, tokens, i)
+	}
+	flush()
+
+	// FM groups run until the next FM group starts.
+	lastFM := -1
+	for i := range periods {
+		if periods[i].Change == "FM" || periods[i].Change == "BASE" {
+			if lastFM >= 0 && periods[i].From.After(periods[lastFM].From) {
+				periods[lastFM].To = periods[i].From
+			}
+			lastFM = i
+		}
+	}
+	return periods, temps, nil
+}
+
+type tafTemp struct {
+	Max  bool
+	C    float64
+	Time time.Time
+}
+
+// finishConditions derives the ceiling, visibility in statute miles and the
+// flight category once all tokens of a report or group have been applied.
+func finishConditions(fc *FlightConditions) {
+	fc.CeilingFt = 0
+	for _, l := range fc.Clouds {
+		if l.Cover == "BKN" || l.Cover == "OVC" || l.Cover == "VV" {
+			if fc.CeilingFt == 0 || l.BaseFt < fc.CeilingFt {
+				fc.CeilingFt = l.BaseFt
+			}
+		}
+	}
+	fc.VisibilitySM = math.Round(fc.VisibilityM/metersPerSM*100) / 100
+	fc.Category = flightCategory(fc.CeilingFt, fc.VisibilitySM)
+}
+
+// flightCategory applies the FAA thresholds. A ceiling of 0 means none.
+func flightCategory(ceilingFt int, visSM float64) string {
+	hasCeiling := ceilingFt > 0
+	switch {
+	case (hasCeiling && ceilingFt < 500) || visSM < 1:
+		return FlightLIFR
+	case (hasCeiling && ceilingFt < 1000) || visSM < 3:
+		return FlightIFR
+	case (hasCeiling && ceilingFt <= 3000) || visSM <= 5:
+		return FlightMVFR
+	default:
+		return FlightVFR
+	}
+}
+
+// resolveDayTime turns a day-of-month/hour/minute from a report into a UTC
+// time, choosing the month that puts it closest to ref. Hour 24 is allowed
+// as TAFs use it for end-of-day.
+func resolveDayTime(ref time.Time, day, hour, minute int) time.Time {
+	ref = ref.UTC()
+	t := time.Date(ref.Year(), ref.Month(), day, hour, minute, 0, 0, time.UTC)
+	switch {
+	case t.Sub(ref) > 15*24*time.Hour:
+		t = time.Date(ref.Year(), ref.Month()-1, day, hour, minute, 0, 0, time.UTC)
+	case ref.Sub(t) > 15*24*time.Hour:
+		t = time.Date(ref.Year(), ref.Month()+1, day, hour, minute, 0, 0, time.UTC)
+	}
+	return t
+}
+
+func parseValidity(tok string, ref time.Time) (from, to time.Time, ok bool) {
+	m := reValidity.FindStringSubmatch(tok)
+	if m == nil {
+		return time.Time{}, time.Time{}, false
+	}
+	from = resolveDayTime(ref, atoi(m[1]), atoi(m[2]), 0)
+	to = resolveDayTime(ref, atoi(m[3]), atoi(m[4]), 0)
+	if to.Before(from) {
+		to = to.AddDate(0, 1, 0)
+	}
+	return from, to, true
+}
+
+func parseVisSM(tok string, whole float64) float64 {
+	m := reVisSM.FindStringSubmatch(tok)
+	v := whole
+	if m[2] != "" {
+		v += float64(atoi(m[2]))
+	}
+	if m[3] != "" && m[4] != "" && atoi(m[4]) != 0 {
+		v += float64(atoi(m[3])) / float64(atoi(m[4]))
+	}
+	if m[1] == "P" {
+		return unlimitedVisM
+	}
+	return math.Round(v * metersPerSM)
+}
+
+func windToKnots(unit string) float64 {
+	switch unit {
+	case "MPS":
+		return 1.943844
+	case "KMH":
+		return 0.539957
+	default:
+		return 1
+	}
+}
+
+func isWeatherToken(tok string) bool {
+	m := reWeather.FindStringSubmatch(tok)
+	if m == nil {
+		return false
+	}
+	// A bare descriptor is only meaningful for TS (thunderstorm) and VCSH
+	// (showers in the vicinity).
+	return m[3] != "" || m[2] == "TS" || (m[1] == "VC" && m[2] == "SH")
+}
+
+func isWholeNumber(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	for _, r := range tok {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(tok) <= 2
+}
+
+func parseSignedTemp(s string) float64 {
+	if strings.HasPrefix(s, "M") {
+		return -float64(atoi(s[1:]))
+	}
+	return float64(atoi(s))
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+var wxWords = map[string]string{
+	"MI": "shallow", "PR": "partial", "BC": "patches of", "DR": "low drifting",
+	"BL": "blowing", "SH": "showers of", "TS": "thunderstorm", "FZ": "freezing",
+	"DZ": "drizzle", "RA": "rain", "SN": "snow", "SG": "snow grains",
+	"IC": "ice crystals", "PL": "ice pellets", "GR": "hail", "GS": "small hail",
+	"UP": "precipitation", "BR": "mist", "FG": "fog", "FU": "smoke",
+	"VA": "volcanic ash", "DU": "dust", "SA": "sand", "HZ": "haze",
+	"PY": "spray", "PO": "dust whirls", "SQ": "squalls", "FC": "funnel cloud",
+	"SS": "sandstorm", "DS": "duststorm",
+}
+
+// describeConditions produces a short plain-English summary: the first
+// weather group if any, otherwise the dominant cloud cover.
+func describeConditions(fc FlightConditions) string {
+	if len(fc.Weather) > 0 {
+		return describeWeather(fc.Weather[0])
+	}
+	cover := ""
+	for _, l := range fc.Clouds {
+		cover = l.Cover
+	}
+	switch cover {
+	case "FEW":
+		return "Few clouds"
+	case "SCT":
+		return "Scattered clouds"
+	case "BKN":
+		return "Broken clouds"
+	case "OVC":
+		return "Overcast"
+	case "VV":
+		return "Sky obscured"
+	default:
+		return "Clear"
+	}
+}
+
+func describeWeather(code string) string {
+	m := reWeather.FindStringSubmatch(code)
+	if m == nil {
+		return code
+	}
+	var words []string
+	switch m[1] {
+	case "-":
+		words = append(words, "light")
+	case "+":
+		words = append(words, "heavy")
+	case "VC":
+		words = append(words, "nearby")
+	}
+	if m[2] != "" {
+		words = append(words, wxWords[m[2]])
+	}
+	if m[2] == "TS" && m[3] != "" {
+		words = append(words, "with")
+	}
+	for p := m[3]; len(p) >= 2; p = p[2:] {
+		words = append(words, wxWords[p[:2]])
+	}
+	s := strings.Join(words, " ")
+	if s == "" {
+		return code
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func conditionsWeatherType(fc FlightConditions) WeatherType {
+	for _, wx := range fc.Weather {
+		switch {
+		case strings.Contains(wx, "TS"), strings.Contains(wx, "SQ"), strings.Contains(wx, "FC"):
+			return Stormy
+		case strings.Contains(wx, "SN"), strings.Contains(wx, "SG"), strings.Contains(wx, "PL"),
+			strings.Contains(wx, "IC"), strings.Contains(wx, "GR"), strings.Contains(wx, "GS"):
+			return Snowy
+		case strings.Contains(wx, "RA"), strings.Contains(wx, "DZ"), strings.Contains(wx, "UP"):
+			return Rainy
+		case strings.Contains(wx, "FG"), strings.Contains(wx, "BR"), strings.Contains(wx, "HZ"), strings.Contains(wx, "FU"):
+			return Foggy
+		}
+	}
+	for _, l := range fc.Clouds {
+		if l.Cover == "BKN" || l.Cover == "OVC" || l.Cover == "VV" || l.Cover == "SCT" {
+			return Cloudy
+		}
+	}
+	return Sunny
+}
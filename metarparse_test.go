@@ -0,0 +1,159 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+var metarRef = time.Date(2024, 5, 18, 12, 30, 0, 0, time.UTC)
+
+func TestParseMETAR(t *testing.T) {
+	tests := []struct {
+		raw      string
+		temp     float64
+		windDir  int
+		windKt   float64
+		gustKt   float64
+		visM     float64
+		ceiling  int
+		category string
+		weather  []string
+	}{
+		{
+			raw:  "EDDB 181150Z 24012G25KT 9999 FEW030 SCT045 17/08 Q1013 NOSIG",
+			temp: 17, windDir: 240, windKt: 12, gustKt: 25, visM: unlimitedVisM,
+			category: FlightVFR,
+		},
+		{
+			raw:  "METAR KJFK 181151Z 00000KT 1 1/2SM BR OVC008 M02/M03 A2992",
+			temp: -2, visM: 2414, ceiling: 800, category: FlightIFR,
+			weather: []string{"BR"},
+		},
+		{
+			raw:  "LFPG 181200Z VRB03KT CAVOK 22/12 Q1018",
+			temp: 22, windKt: 3, visM: unlimitedVisM, category: FlightVFR,
+		},
+		{
+			raw:  "UUEE 181200Z 18005MPS 0800 +TSRA BKN004CB 15/14 Q1002",
+			temp: 15, windDir: 180, windKt: 10, visM: 800, ceiling: 400,
+			category: FlightLIFR, weather: []string{"+TSRA"},
+		},
+	}
+	for _, tt := range tests {
+		av, temp, err := parseMETAR(tt.raw, metarRef)
+		if err != nil {
+			t.Errorf("parseMETAR(%q): %v", tt.raw, err)
+			continue
+		}
+		if temp != tt.temp || av.WindDirDeg != tt.windDir || av.WindKt != tt.windKt || av.GustKt != tt.gustKt {
+			t.Errorf("parseMETAR(%q): temp %v wind %d/%v G%v, want %v %d/%v G%v",
+				tt.raw, temp, av.WindDirDeg, av.WindKt, av.GustKt, tt.temp, tt.windDir, tt.windKt, tt.gustKt)
+		}
+		if av.VisibilityM != tt.visM || av.CeilingFt != tt.ceiling || av.Category != tt.category {
+			t.Errorf("parseMETAR(%q): vis %v ceiling %d %s, want %v %d %s",
+				tt.raw, av.VisibilityM, av.CeilingFt, av.Category, tt.visM, tt.ceiling, tt.category)
+		}
+		if len(av.Weather) != len(tt.weather) || (len(tt.weather) > 0 && av.Weather[0] != tt.weather[0]) {
+			t.Errorf("parseMETAR(%q): weather %v, want %v", tt.raw, av.Weather, tt.weather)
+		}
+	}
+}
+
+func TestParseMETARErrors(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"berlin 181150Z 24012KT 9999 17/08",
+		"EDDB 1811Z 24012KT 9999 17/08",
+		"EDDB 181150Z 24012KT 9999 FEW030",
+	} {
+		if _, _, err := parseMETAR(raw, metarRef); err == nil {
+			t.Errorf("parseMETAR(%q): want error", raw)
+		}
+	}
+}
+
+func TestDescribeWeather(t *testing.T) {
+	tests := map[string]string{
+		"TSRA":   "Thunderstorm with rain",
+		"+TSRA":  "Heavy thunderstorm with rain",
+		"TS":     "Thunderstorm",
+		"-SHRA":  "Light showers of rain",
+		"FZDZ":   "Freezing drizzle",
+		"VCSH":   "Nearby showers of",
+		"RASN":   "Rain snow",
+		"BR":     "Mist",
+		"XYZ123": "XYZ123",
+	}
+	for code, want := range tests {
+		if got := describeWeather(code); got != want {
+			t.Errorf("describeWeather(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestParseTAF(t *testing.T) {
+	raw := "TAF EDDB 181100Z 1812/1918 24010KT 9999 SCT040 TX21/1814Z TN09/1905Z" +
+		" TEMPO 1814/1818 TSRA BKN020CB" +
+		" FM190000 20005KT 4000 BR OVC008" +
+		" BECMG 1906/1908 9999 SCT030"
+	periods, temps, err := parseTAF(raw, metarRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		change   string
+		from, to time.Time
+		category string
+	}{
+		{"BASE", utc(18, 12), utc(19, 0), FlightVFR},
+		{"TEMPO", utc(18, 14), utc(18, 18), FlightMVFR},
+		{"FM", utc(19, 0), utc(19, 18), FlightIFR},
+		{"BECMG", utc(19, 6), utc(19, 8), FlightVFR},
+	}
+	if len(periods) != len(want) {
+		t.Fatalf("got %d periods, want %d", len(periods), len(want))
+	}
+	for i, w := range want {
+		p := periods[i]
+		if p.Change != w.change || !p.From.Equal(w.from) || !p.To.Equal(w.to) || p.Category != w.category {
+			t.Errorf("period %d: %s %s-%s %s, want %s %s-%s %s", i,
+				p.Change, p.From, p.To, p.Category, w.change, w.from, w.to, w.category)
+		}
+	}
+	if len(temps) != 2 || !temps[0].Max || temps[0].C != 21 || temps[1].Max || temps[1].C != 9 {
+		t.Errorf("temps = %+v, want TX21 and TN09", temps)
+	}
+}
+
+func TestDecodeAviationForecastDays(t *testing.T) {
+	metar := "EDDB 181150Z 24012KT 9999 FEW030 17/08 Q1013"
+	taf := "TAF EDDB 181100Z 1812/1918 24010KT 9999 SCT040 TX21/1814Z" +
+		" FM181800 20005KT 4000 BR OVC008 FM190900 24010KT 9999 SCT030"
+	info, err := decodeAviation(metar, taf, Config{Forecast: 3}, metarRef)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Forecast) != 2 {
+		t.Fatalf("got %d forecast days, want 2", len(info.Forecast))
+	}
+	today, tomorrow := info.Forecast[0], info.Forecast[1]
+	if !today.Date.Equal(utc(18, 0)) || !tomorrow.Date.Equal(utc(19, 0)) {
+		t.Errorf("dates %s, %s, want UTC midnights", today.Date, tomorrow.Date)
+	}
+	if today.NoMax || today.MaxTempC != 21 || !today.NoMin {
+		t.Errorf("today: max %v (missing %v), min missing %v; want max 21, no min", today.MaxTempC, today.NoMax, today.NoMin)
+	}
+	if !tomorrow.NoMin || !tomorrow.NoMax {
+		t.Errorf("tomorrow: temperatures should be missing")
+	}
+	if today.Description != "Scattered clouds" || tomorrow.Description != "Scattered clouds" {
+		t.Errorf("descriptions %q, %q, want the groups prevailing at noon", today.Description, tomorrow.Description)
+	}
+	if len(info.Aviation.Periods) != 3 {
+		t.Errorf("got %d TAF periods, want 3", len(info.Aviation.Periods))
+	}
+}
+
+func utc(day, hour int) time.Time {
+	return time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const ProviderMetNo = "met.no"
+
+type metNoProvider struct{}
+
+func init() {
+	RegisterProvider(metNoProvider{})
+}
+
+type metNoSummary struct {
+	Summary struct {
+		SymbolCode string `json:"symbol_code"`
+	} `json:"summary"`
+}
+
+type metNoResponse struct {
+	Geometry struct {
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Timeseries []struct {
+			Time time.Time `json:"time"`
+			Data struct {
+				Instant struct {
+					Details struct {
+						AirTemperature *float64 `json:"air_temperature"`
+						UVIndex        float64  `json:"ultraviolet_index_clear_sky"`
+					} `json:"details"`
+				} `json:"instant"`
+				Next1Hours  *metNoSummary `json:"next_1_hours"`
+				Next6Hours  *metNoSummary `json:"next_6_hours"`
+				Next12Hours *metNoSummary `json:"next_12_hours"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+func (metNoProvider) Name() string { return ProviderMetNo }
+
+func (metNoProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 9}
+}
+
+// BuildRequest geocodes through Open-Meteo since met.no has no place search
+// of its own. The Locationforecast terms ask for at most four decimals.
+func (metNoProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	c, err := geocodeOpenMeteo(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(baseURL(config, ProviderMetNo) + "/locationforecast/2.0/compact")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse met.no URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("lat", formatCoord(c.Lat))
+	q.Set("lon", formatCoord(c.Lon))
+	u.RawQuery = q.Encode()
+	return newRequest(ctx, u.String())
+}
+
+func (metNoProvider) CheckStatus(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo:
+		return nil
+	case http.StatusForbidden:
+		return errors.New("forbidden: met.no rejected the request (check User-Agent)")
+	case http.StatusTooManyRequests:
+		return errors.New("rate limited: met.no is throttling requests")
+	default:
+		return unexpectedStatus(resp)
+	}
+}
+
+func (metNoProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var r metNoResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	series := r.Properties.Timeseries
+	if len(series) == 0 || series[0].Data.Instant.Details.AirTemperature == nil {
+		return WeatherInfo{}, errors.New("no current condition data in response")
+	}
+
+	now := series[0].Data
+	symbol := firstSymbol(now.Next1Hours, now.Next6Hours, now.Next12Hours)
+	temp := *now.Instant.Details.AirTemperature
+	info := WeatherInfo{
+		Description: metNoDescription(symbol),
+		TempC:       temp,
+		TempF:       celsiusToFahrenheit(temp),
+		UVIndex:     now.Instant.Details.UVIndex,
+		Type:        metNoWeatherType(symbol),
+	}
+
+	if config.Forecast > 0 {
+		// Timeseries are in UTC and met.no does not report the zone of the
+		// point, so days are split on solar time derived from longitude.
+		var offset time.Duration
+		if len(r.Geometry.Coordinates) > 0 {
+			offset = time.Duration(math.Round(r.Geometry.Coordinates[0]/15)) * time.Hour
+		}
+
+		type dayAcc struct {
+			day      ForecastDay
+			noonDist time.Duration
+			seen     bool
+		}
+		var days []*dayAcc
+		for _, ts := range series {
+			t := ts.Time.Add(offset)
+			date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+			if len(days) == 0 || !days[len(days)-1].day.Date.Equal(date) {
+				days = append(days, &dayAcc{day: ForecastDay{Date: date}})
+			}
+			acc := days[len(days)-1]
+
+			if p := ts.Data.Instant.Details.AirTemperature; p != nil {
+				if !acc.seen || *p < acc.day.MinTempC {
+					acc.day.MinTempC = *p
+				}
+				if !acc.seen || *p > acc.day.MaxTempC {
+					acc.day.MaxTempC = *p
+				}
+				acc.seen = true
+			}
+
+			sym := firstSymbol(ts.Data.Next6Hours, ts.Data.Next1Hours, ts.Data.Next12Hours)
+			if sym == "" {
+				continue
+			}
+			dist := t.Sub(date.Add(12 * time.Hour)).Abs()
+			if acc.day.Description == "" || dist < acc.noonDist {
+				acc.day.Description = metNoDescription(sym)
+				acc.day.Type = metNoWeatherType(sym)
+				acc.noonDist = dist
+			}
+		}
+
+		for _, acc := range days {
+			if len(info.Forecast) == config.Forecast {
+				break
+			}
+			if !acc.seen {
+				continue
+			}
+			d := acc.day
+			d.MinTempF = celsiusToFahrenheit(d.MinTempC)
+			d.MaxTempF = celsiusToFahrenheit(d.MaxTempC)
+			info.Forecast = append(info.Forecast, d)
+		}
+	}
+	return info, nil
+}
+
+func firstSymbol(summaries ...*metNoSummary) string {
+	for _, s := range summaries {
+		if s != nil && s.Summary.SymbolCode != "" {
+			return s.Summary.SymbolCode
+		}
+	}
+	return ""
+}
+
+// splitMetNoSymbol separates a symbol code such as "partlycloudy_night" into
+// its condition and the day/night/polartwilight variant.
+func splitMetNoSymbol(symbol string) (base, variant string) {
+	base, variant, _ = strings.Cut(symbol, "_")
+	return base, variant
+}
+
+var metNoWords = []string{"partlycloudy", "clearsky", "cloudy", "showers", "thunder", "light", "heavy", "sleet", "snow", "rain", "fair", "fog", "and"}
+
+// metNoDescription turns a symbol code into readable text by splitting the
+// run-together words, e.g. "lightrainshowers_day" -> "Light rain showers".
+func metNoDescription(symbol string) string {
+	base, _ := splitMetNoSymbol(symbol)
+	if base == "" {
+		return "Unknown"
+	}
+	var words []string
+	rest := base
+	for rest != "" {
+		matched := false
+		for _, w := range metNoWords {
+			if strings.HasPrefix(rest, w) {
+				switch w {
+				case "partlycloudy":
+					words = append(words, "partly", "cloudy")
+				case "clearsky":
+					words = append(words, "clear", "sky")
+				default:
+					words = append(words, w)
+				}
+				rest = rest[len(w):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return base
+		}
+	}
+	s := strings.Join(words, " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func metNoWeatherType(symbol string) WeatherType {
+	base, variant := splitMetNoSymbol(symbol)
+	switch {
+	case base == "":
+		return Unknown
+	case strings.Contains(base, "thunder"):
+		return Stormy
+	case strings.Contains(base, "snow"), strings.Contains(base, "sleet"):
+		return Snowy
+	case strings.Contains(base, "rain"):
+		return Rainy
+	case base == "fog":
+		return Foggy
+	case base == "clearsky", base == "fair":
+		if variant == "night" {
+			return ClearNight
+		}
+		return Sunny
+	case base == "partlycloudy", base == "cloudy":
+		return Cloudy
+	default:
+		return Unknown
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SortName = "name"
+	SortTemp = "temp"
+
+	defaultConcurrency = 4
+
+	cityNameW   = 20
+	cityTempW   = 10
+	cityCondW   = 24
+	cityUVW     = 6
+	cityDayW    = 12
+	citySourceW = 16
+)
+
+// cityList collects repeated -city flags.
+type cityList []string
+
+func (c *cityList) String() string { return strings.Join(*c, ", ") }
+
+func (c *cityList) Set(v string) error {
+	if v = strings.TrimSpace(v); v != "" {
+		*c = append(*c, v)
+	}
+	return nil
+}
+
+// cityReport is one row of a multi-city run. The embedded report is nil
+// when the city failed, so JSON output carries either it or error.
+type cityReport struct {
+	City  string `json:"city"`
+	Error string `json:"error,omitempty"`
+	*WeatherInfo
+	err error
+}
+
+// runMultiCity fetches every city in cfg.Cities with at most
+// cfg.Concurrency requests in flight and renders them as one table.
+func runMultiCity(ctx context.Context, cfg Config, out io.Writer) error {
+	reports := fetchCities(ctx, cfg)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ok := 0
+	for _, r := range reports {
+		if r.err == nil {
+			ok++
+		} else if !cfg.Quiet {
+			fmt.Fprintf(os.Stderr, "wrep: %s: %v\n", r.City, r.err)
+		}
+	}
+	if ok == 0 {
+		return errors.New("no city could be fetched")
+	}
+
+	sortCityReports(reports, cfg.Sort)
+	if cfg.JSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reports)
+	}
+	renderCities(out, reports, cfg)
+	return nil
+}
+
+// fetchCities runs a fixed pool of workers over the cities. Each city goes
+// through the full FetchWeather path (cache, fallback chain, retries), so
+// it behaves exactly like a single-city run.
+func fetchCities(ctx context.Context, cfg Config) []cityReport {
+	reports := make([]cityReport, len(cfg.Cities))
+	jobs := make(chan int)
+	workers := min(max(cfg.Concurrency, 1), len(cfg.Cities))
+
+	fetch := FetchWeather
+	if cfg.Consensus {
+		fetch = FetchConsensus
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c := cfg
+				c.City = cfg.Cities[i]
+				info, err := fetch(ctx, c)
+				reports[i] = cityReport{City: c.City, err: err}
+				if err != nil {
+					reports[i].Error = err.Error()
+				} else {
+					reports[i].WeatherInfo = &info
+				}
+			}
+		}()
+	}
+	for i := range cfg.Cities {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return reports
+}
+
+// sortCityReports orders by name, or by current temperature (warmest
+// first, ties by name). Failed cities always go last.
+func sortCityReports(reports []cityReport, by string) {
+	sort.SliceStable(reports, func(i, j int) bool {
+		a, b := reports[i], reports[j]
+		if (a.err == nil) != (b.err == nil) {
+			return a.err == nil
+		}
+		if by == SortTemp && a.err == nil && a.TempC != b.TempC {
+			return a.TempC > b.TempC
+		}
+		return strings.ToLower(a.City) < strings.ToLower(b.City)
+	})
+}
+
+func renderCities(w io.Writer, reports []cityReport, config Config) {
+	color := useColor(config)
+	days := config.Forecast
+
+	widths := []int{cityNameW, cityTempW, cityCondW, cityUVW}
+	headers := []string{" City", " Now", " Conditions", " UV"}
+	for d := 0; d < days; d++ {
+		widths = append(widths, cityDayW)
+		headers = append(headers, fmt.Sprintf(" Day %d", d+1))
+	}
+	widths = append(widths, citySourceW)
+	headers = append(headers, " Provider")
+	for i := range headers {
+		headers[i] = padRight(headers[i], widths[i])
+		if color {
+			headers[i] = Bold + headers[i] + Reset
+		}
+	}
+	top, mid, bot := tableBorders(widths)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, top)
+	fmt.Fprintln(w, tableRow(headers))
+	fmt.Fprintln(w, mid)
+	for _, r := range reports {
+		cells := []string{padRight(" "+r.City, cityNameW)}
+		if r.err != nil {
+			cells = append(cells, padRight(" -", cityTempW), padRight(" "+truncate("error: "+r.Error, cityCondW-1), cityCondW), padRight(" -", cityUVW))
+			for d := 0; d < days; d++ {
+				cells = append(cells, padRight(" -", cityDayW))
+			}
+			cells = append(cells, padRight(" -", citySourceW))
+			if color {
+				cells[2] = Red + cells[2] + Reset
+			}
+			fmt.Fprintln(w, tableRow(cells))
+			continue
+		}
+
+		cond := r.Description
+		if r.Stale != nil {
+			cond = "(stale " + formatAge(time.Duration(r.Stale.AgeSeconds)*time.Second) + ") " + cond
+		}
+		condCell := padRight(" "+truncate(cond, cityCondW-1), cityCondW)
+		if color {
+			condCell = WeatherColor(r.Type) + condCell + Reset
+		}
+		cells = append(cells,
+			padRight(" "+formatTemp(r.TempC, r.TempF, config.Unit), cityTempW),
+			condCell,
+			padRight(" "+formatUV(r.UVIndex), cityUVW),
+		)
+		for d := 0; d < days; d++ {
+			text := " -"
+			if d < len(r.Forecast) {
+				text = " " + formatTempRange(r.Forecast[d], config.Unit)
+			}
+			cells = append(cells, padRight(text, cityDayW))
+		}
+		cells = append(cells, padRight(" "+r.Provider, citySourceW))
+		fmt.Fprintln(w, tableRow(cells))
+	}
+	fmt.Fprintln(w, bot)
+	fmt.Fprintln(w)
+}
+
+// rateLimiter spaces out fetches per provider so a long city list doesn't
+// trip a provider's rate limit. It hands out start slots at fixed intervals.
+type rateLimiter struct {
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+var providerLimiter = rateLimiter{next: map[string]time.Time{}}
+
+// wait blocks until provider may start another fetch at perSecond fetches
+// per second. A perSecond of 0 means unlimited.
+func (l *rateLimiter) wait(ctx context.Context, provider string, perSecond float64) error {
+	if perSecond <= 0 {
+		return nil
+	}
+	gap := time.Duration(float64(time.Second) / perSecond)
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[provider]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[provider] = slot.Add(gap)
+	l.mu.Unlock()
+	return sleepCtx(ctx, slot.Sub(now))
+}
+
+// rateLimitFor returns the configured fetches per second for provider:
+// rateLimit.<provider>, falling back to rateLimit.
+func rateLimitFor(config Config, provider string) float64 {
+	if r, ok := config.RateLimits[provider]; ok {
+		return r
+	}
+	return config.RateLimit
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout        = 30 * time.Second
+	defaultConnectTimeout = 10 * time.Second
+	// geocodingOpenMeteo names Open-Meteo's geocoding API for baseURL
+	// overrides; it lives on a different host from the forecast API.
+	geocodingOpenMeteo = "open-meteo-geocoding"
+)
+
+// defaultBaseURLs are the API roots each provider builds its URLs on. They
+// can be pointed elsewhere, e.g. at a wttr.in mirror, with baseURL.<name>.
+var defaultBaseURLs = map[string]string{
+	ProviderWttr:           "https://wttr.in",
+	ProviderWeatherAPI:     "https://api.weatherapi.com/v1",
+	ProviderOpenMeteo:      "https://api.open-meteo.com/v1",
+	geocodingOpenMeteo:     "https://geocoding-api.open-meteo.com/v1",
+	ProviderOpenWeatherMap: "https://api.openweathermap.org",
+	ProviderMetNo:          "https://api.met.no/weatherapi",
+	ProviderNWS:            "https://api.weather.gov",
+	ProviderMETAR:          "https://aviationweather.gov/api/data",
+	meteoAlarm:             "https://feeds.meteoalarm.org/api/v1/warnings",
+}
+
+func baseURL(config Config, name string) string {
+	if u := config.BaseURLs[name]; u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return defaultBaseURLs[name]
+}
+
+func validateBaseURLs(overrides map[string]string) error {
+	for name, raw := range overrides {
+		if _, ok := defaultBaseURLs[name]; !ok {
+			names := make([]string, 0, len(defaultBaseURLs))
+			for n := range defaultBaseURLs {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("baseURL.%s: no such endpoint (want one of %s)", name, strings.Join(names, ", "))
+		}
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("baseURL.%s: %q is not an http(s) URL", name, raw)
+		}
+	}
+	return nil
+}
+
+// configureHTTP rebuilds httpClient from the network settings: proxy,
+// extra CA certificates and timeouts. Without a proxy setting the usual
+// HTTPS_PROXY/HTTP_PROXY/NO_PROXY environment variables apply.
+func configureHTTP(config Config) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   config.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = config.ConnectTimeout
+
+	if config.Proxy != "" {
+		u, err := url.Parse(config.Proxy)
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid proxy %q", config.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if config.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, path := range splitList(config.CAFile) {
+			pem, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read CA bundle: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no PEM certificates found in %s", path)
+			}
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	httpClient = &http.Client{Transport: transport, Timeout: config.Timeout}
+	return nil
+}
+
+// validateTimeouts fills in the default timeouts and rejects nonsense.
+func validateTimeouts(config *Config) error {
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.ConnectTimeout == 0 {
+		config.ConnectTimeout = min(defaultConnectTimeout, config.Timeout)
+	}
+	if config.Timeout < 0 || config.ConnectTimeout < 0 {
+		return errors.New("timeouts must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	CommandNowcast = "nowcast"
+
+	defaultNowcastWindow = 2 * time.Hour
+	maxNowcastWindow     = 12 * time.Hour
+	nowcastStep          = 15 * time.Minute
+	nowcastStripW        = 48
+	// nowcastWetMM is the least precipitation per step that counts as rain.
+	nowcastWetMM = 0.1
+)
+
+// Nowcast is precipitation in 15-minute steps for the next few hours.
+// StartsAt and EndsAt bound the first wet spell in the window; EndsAt is
+// nil when it lasts past the window.
+type Nowcast struct {
+	City     string        `json:"city"`
+	Provider string        `json:"provider"`
+	Steps    []NowcastStep `json:"steps"`
+	Summary  string        `json:"summary"`
+	Raining  bool          `json:"raining"`
+	StartsAt *time.Time    `json:"starts_at,omitempty"`
+	EndsAt   *time.Time    `json:"ends_at,omitempty"`
+}
+
+// NowcastStep is the precipitation expected from Time until 15 minutes
+// later.
+type NowcastStep struct {
+	Time     time.Time `json:"time"`
+	PrecipMM float64   `json:"precip_mm"`
+	PrecipIn float64   `json:"precip_in"`
+}
+
+type openMeteoMinutelyResponse struct {
+	UTCOffsetSeconds int `json:"utc_offset_seconds"`
+	Minutely15       struct {
+		Time          []string  `json:"time"`
+		Precipitation []float64 `json:"precipitation"`
+	} `json:"minutely_15"`
+}
+
+// runNowcast answers "will it rain in the next hour or two" from Open-Meteo's
+// 15-minute precipitation.
+func runNowcast(ctx context.Context, cfg Config, out io.Writer) error {
+	nc, err := fetchNowcast(ctx, cfg, time.Now())
+	if err != nil {
+		return err
+	}
+	if cfg.JSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(nc)
+	}
+	renderNowcast(out, nc, cfg)
+	return nil
+}
+
+func fetchNowcast(ctx context.Context, config Config, now time.Time) (Nowcast, error) {
+	c, err := geocodeOpenMeteo(ctx, config)
+	if err != nil {
+		return Nowcast{}, err
+	}
+	u, err := url.Parse(baseURL(config, ProviderOpenMeteo) + "/forecast")
+	if err != nil {
+		return Nowcast{}, fmt.Errorf("failed to parse open-meteo URL: %w", err)
+	}
+	// One step more than the window, since the first one has partly
+	// passed already.
+	steps := int(config.NowcastWindow/nowcastStep) + 1
+	q := u.Query()
+	q.Set("latitude", formatCoord(c.Lat))
+	q.Set("longitude", formatCoord(c.Lon))
+	q.Set("minutely_15", "precipitation")
+	q.Set("forecast_minutely_15", strconv.Itoa(steps))
+	q.Set("timezone", "auto")
+	u.RawQuery = q.Encode()
+
+	var r openMeteoMinutelyResponse
+	if err := getJSON(ctx, u.String(), config, checkOK, &r); err != nil {
+		return Nowcast{}, err
+	}
+	if len(r.Minutely15.Time) != len(r.Minutely15.Precipitation) {
+		return Nowcast{}, fmt.Errorf("malformed minutely_15 data: %d times, %d values", len(r.Minutely15.Time), len(r.Minutely15.Precipitation))
+	}
+
+	zone := time.FixedZone("", r.UTCOffsetSeconds)
+	nc := Nowcast{City: config.City, Provider: ProviderOpenMeteo}
+	end := now.Add(config.NowcastWindow)
+	for i, s := range r.Minutely15.Time {
+		t, err := time.ParseInLocation("2006-01-02T15:04", s, zone)
+		if err != nil {
+			return Nowcast{}, fmt.Errorf("unrecognized time %q in minutely_15 data", s)
+		}
+		// Open-Meteo labels each value with the end of its 15 minutes.
+		t = t.Add(-nowcastStep)
+		if !t.Add(nowcastStep).After(now) || !t.Before(end) {
+			continue
+		}
+		mm := r.Minutely15.Precipitation[i]
+		nc.Steps = append(nc.Steps, NowcastStep{
+			Time:     t,
+			PrecipMM: mm,
+			PrecipIn: math.Round(mm/25.4*1000) / 1000,
+		})
+	}
+	if len(nc.Steps) == 0 {
+		return Nowcast{}, fmt.Errorf("no minutely_15 data for %s", config.City)
+	}
+	summarizeNowcast(&nc, config)
+	return nc, nil
+}
+
+// summarizeNowcast finds the first wet spell and phrases it.
+func summarizeNowcast(nc *Nowcast, config Config) {
+	start, stop := -1, -1
+	heaviest := 0
+	for i, s := range nc.Steps {
+		wet := s.PrecipMM >= nowcastWetMM
+		switch {
+		case wet && start < 0:
+			start, heaviest = i, i
+		case !wet && start >= 0 && stop < 0:
+			stop = i
+		case wet && stop < 0 && s.PrecipMM > nc.Steps[heaviest].PrecipMM:
+			heaviest = i
+		}
+	}
+	window := formatWindow(config.NowcastWindow)
+	if start < 0 {
+		nc.Summary = "No rain expected in the next " + window
+		return
+	}
+
+	nc.Raining = start == 0
+	startsAt := nc.Steps[start].Time
+	if !nc.Raining {
+		nc.StartsAt = &startsAt
+	}
+	if stop >= 0 {
+		endsAt := nc.Steps[stop].Time
+		nc.EndsAt = &endsAt
+	}
+	peak := nc.Steps[heaviest]
+	peakText := fmt.Sprintf("heaviest %s around %s", formatPrecip(peak.PrecipMM, peak.PrecipIn, config.Unit), peak.Time.Format("15:04"))
+	switch {
+	case nc.Raining && nc.EndsAt != nil:
+		nc.Summary = fmt.Sprintf("Rain now, stopping around %s (%s)", nc.EndsAt.Format("15:04"), peakText)
+	case nc.Raining:
+		nc.Summary = fmt.Sprintf("Rain now, continuing for at least %s (%s)", window, peakText)
+	case nc.EndsAt != nil:
+		nc.Summary = fmt.Sprintf("Rain from %s until around %s (%s)", startsAt.Format("15:04"), nc.EndsAt.Format("15:04"), peakText)
+	default:
+		nc.Summary = fmt.Sprintf("Rain from %s (%s)", startsAt.Format("15:04"), peakText)
+	}
+}
+
+func formatWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		if d == time.Hour {
+			return "hour"
+		}
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	}
+	return fmt.Sprintf("%d minutes", int(d/time.Minute))
+}
+
+// nowcastBars are precipitation intensities from light to heavy.
+var nowcastBars = []rune("▁▂▃▄▅▆▇█")
+
+// nowcastBar draws one step: "·" when dry, otherwise a bar whose height
+// grows with the amount, full at 2.5 mm in 15 minutes (10 mm/h).
+func nowcastBar(mm float64) string {
+	if mm < nowcastWetMM {
+		return "·"
+	}
+	i := int(mm / 2.5 * float64(len(nowcastBars)-1))
+	return string(nowcastBars[min(i, len(nowcastBars)-1)])
+}
+
+func renderNowcast(w io.Writer, nc Nowcast, config Config) {
+	color := useColor(config)
+	// Short windows get wider steps so the strip stays readable.
+	width := max(1, nowcastStripW/len(nc.Steps))
+	var strip strings.Builder
+	for _, s := range nc.Steps {
+		bar := strings.Repeat(nowcastBar(s.PrecipMM), width)
+		if color && s.PrecipMM >= nowcastWetMM {
+			bar = Blue + bar + Reset
+		}
+		strip.WriteString(bar)
+	}
+
+	summary := nc.Summary
+	if config.Fancy {
+		wt := Sunny
+		if nc.Raining || nc.StartsAt != nil {
+			wt = Rainy
+		}
+		summary = WeatherEmoji(wt) + " " + summary
+	}
+	if color && (nc.Raining || nc.StartsAt != nil) {
+		summary = Blue + summary + Reset
+	}
+
+	first, last := nc.Steps[0].Time, nc.Steps[len(nc.Steps)-1].Time.Add(nowcastStep)
+	fmt.Fprintln(w, summary)
+	fmt.Fprintf(w, "%s %s %s\n", first.Format("15:04"), strip.String(), last.Format("15:04"))
+}
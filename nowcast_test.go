@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeNowcast(t *testing.T) {
+	start := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	at := func(step int) *time.Time {
+		t := start.Add(time.Duration(step) * nowcastStep)
+		return &t
+	}
+	tests := []struct {
+		name     string
+		mm       []float64
+		summary  string
+		raining  bool
+		startsAt *time.Time
+		endsAt   *time.Time
+	}{
+		{
+			name:    "dry",
+			mm:      []float64{0, 0.05, 0, 0},
+			summary: "No rain expected in the next 2 hours",
+		},
+		{
+			name:    "raining, stopping",
+			mm:      []float64{0.4, 1.2, 0.3, 0, 0},
+			summary: "Rain now, stopping around 12:45 (heaviest 1.2 mm around 12:15)",
+			raining: true,
+			endsAt:  at(3),
+		},
+		{
+			name:    "raining throughout",
+			mm:      []float64{0.2, 0.2, 0.5},
+			summary: "Rain now, continuing for at least 2 hours (heaviest 0.5 mm around 12:30)",
+			raining: true,
+		},
+		{
+			name:     "starting and stopping",
+			mm:       []float64{0, 0, 0.1, 0.8, 0, 2.0},
+			summary:  "Rain from 12:30 until around 13:00 (heaviest 0.8 mm around 12:45)",
+			startsAt: at(2),
+			endsAt:   at(4),
+		},
+		{
+			name:     "starting",
+			mm:       []float64{0, 0.3, 0.6, 0.6},
+			summary:  "Rain from 12:15 (heaviest 0.6 mm around 12:30)",
+			startsAt: at(1),
+		},
+	}
+	for _, tt := range tests {
+		nc := Nowcast{}
+		for i, mm := range tt.mm {
+			nc.Steps = append(nc.Steps, NowcastStep{Time: *at(i), PrecipMM: mm})
+		}
+		summarizeNowcast(&nc, Config{Unit: UnitMetric, NowcastWindow: defaultNowcastWindow})
+		if nc.Summary != tt.summary || nc.Raining != tt.raining {
+			t.Errorf("%s: %q (raining %v), want %q (raining %v)", tt.name, nc.Summary, nc.Raining, tt.summary, tt.raining)
+		}
+		if !sameTime(nc.StartsAt, tt.startsAt) || !sameTime(nc.EndsAt, tt.endsAt) {
+			t.Errorf("%s: spell %v-%v, want %v-%v", tt.name, nc.StartsAt, nc.EndsAt, tt.startsAt, tt.endsAt)
+		}
+	}
+}
+
+func TestFormatWindow(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		time.Hour:        "hour",
+		3 * time.Hour:    "3 hours",
+		90 * time.Minute: "90 minutes",
+		45 * time.Minute: "45 minutes",
+	} {
+		if got := formatWindow(d); got != want {
+			t.Errorf("formatWindow(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
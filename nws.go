@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ProviderNWS = "nws"
+
+type nwsProvider struct{}
+
+func init() {
+	RegisterProvider(nwsProvider{})
+}
+
+type nwsPoint struct {
+	Forecast       string `json:"forecast"`
+	ForecastHourly string `json:"forecastHourly"`
+}
+
+type nwsPointResponse struct {
+	Properties nwsPoint `json:"properties"`
+}
+
+type nwsPeriod struct {
+	StartTime       time.Time `json:"startTime"`
+	IsDaytime       bool      `json:"isDaytime"`
+	Temperature     float64   `json:"temperature"`
+	TemperatureUnit string    `json:"temperatureUnit"`
+	ShortForecast   string    `json:"shortForecast"`
+}
+
+type nwsForecastResponse struct {
+	Properties struct {
+		Periods []nwsPeriod `json:"periods"`
+	} `json:"properties"`
+}
+
+type nwsAlertsResponse struct {
+	Features []struct {
+		Properties struct {
+			Event       string `json:"event"`
+			Headline    string `json:"headline"`
+			Severity    string `json:"severity"`
+			Urgency     string `json:"urgency"`
+			AreaDesc    string `json:"areaDesc"`
+			Onset       string `json:"onset"`
+			Expires     string `json:"expires"`
+			Ends        string `json:"ends"`
+			Description string `json:"description"`
+			Instruction string `json:"instruction"`
+			MessageType string `json:"messageType"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+type nwsProblem struct {
+	Title  string `json:"title"`
+	Detail string `json:"detail"`
+}
+
+var (
+	nwsPointMu    sync.Mutex
+	nwsPointCache = map[string]nwsPoint{}
+)
+
+func (nwsProvider) Name() string { return ProviderNWS }
+
+func (nwsProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 7, Alerts: true}
+}
+
+// BuildRequest returns the hourly forecast request; its first period is
+// the closest thing NWS has to current conditions.
+func (nwsProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	pt, err := nwsGridpoint(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	return newRequest(ctx, nwsURL(config, pt.ForecastHourly))
+}
+
+func (nwsProvider) CheckStatus(resp *http.Response) error {
+	return nwsStatus(resp)
+}
+
+// nwsStatus classifies an api.weather.gov response, surfacing the detail
+// from its problem+json error bodies.
+func nwsStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var p nwsProblem
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_ = json.Unmarshal(body, &p)
+	msg := strings.TrimSpace(p.Detail)
+	if msg == "" {
+		msg = strings.TrimSpace(p.Title)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		if msg == "" {
+			msg = "location not covered by the National Weather Service"
+		}
+		return fmt.Errorf("not found: %s", msg)
+	}
+	if msg != "" {
+		return fmt.Errorf("unexpected HTTP status: %s (%s)", resp.Status, msg)
+	}
+	return unexpectedStatus(resp)
+}
+
+func (nwsProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var r nwsForecastResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	if len(r.Properties.Periods) == 0 {
+		return WeatherInfo{}, errors.New("no current condition data in response")
+	}
+	now := r.Properties.Periods[0]
+	c, f := nwsTemps(now)
+	info := WeatherInfo{
+		Description: strings.TrimSpace(now.ShortForecast),
+		TempC:       c,
+		TempF:       f,
+		Type:        nwsWeatherType(now),
+	}
+	return info, nil
+}
+
+// Fetch runs the hourly request through the usual cycle, adds active
+// alerts and, when a forecast is wanted, the day/night periods from
+// /forecast.
+func (p nwsProvider) Fetch(ctx context.Context, config Config) (WeatherInfo, error) {
+	req, err := p.BuildRequest(ctx, config)
+	if err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to build request: %w", err)
+	}
+	body, err := fetchBody(req, config, p.CheckStatus)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	info, err := p.Parse(body, config)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	if !config.NoAlerts {
+		// Alerts are extra; losing them must not lose the report,
+		// unless they are all that was asked for.
+		alerts, err := nwsAlerts(ctx, config)
+		if err != nil && config.AlertsOnly {
+			return WeatherInfo{}, fmt.Errorf("alerts: %w", err)
+		}
+		if err != nil && !config.Quiet {
+			fmt.Fprintln(os.Stderr, "wrep: nws alerts:", err)
+		}
+		info.Alerts = alerts
+		info.AlertsFailed = err != nil
+	}
+	if config.Forecast == 0 {
+		return info, nil
+	}
+
+	pt, err := nwsGridpoint(ctx, config)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	var r nwsForecastResponse
+	if err := getJSON(ctx, nwsURL(config, pt.Forecast), config, nwsStatus, &r); err != nil {
+		return WeatherInfo{}, err
+	}
+	info.Forecast = foldNWSPeriods(r.Properties.Periods)
+	if len(info.Forecast) > config.Forecast {
+		info.Forecast = info.Forecast[:config.Forecast]
+	}
+	return info, nil
+}
+
+// nwsGridpoint resolves config.City to the forecast URLs of its NWS grid
+// cell. Both the geocoding and /points lookups are cached for the process.
+func nwsGridpoint(ctx context.Context, config Config) (nwsPoint, error) {
+	c, err := geocodeOpenMeteo(ctx, config)
+	if err != nil {
+		return nwsPoint{}, err
+	}
+	key := formatCoord(c.Lat) + "," + formatCoord(c.Lon)
+
+	nwsPointMu.Lock()
+	pt, ok := nwsPointCache[key]
+	nwsPointMu.Unlock()
+	if ok {
+		return pt, nil
+	}
+
+	var r nwsPointResponse
+	if err := getJSON(ctx, baseURL(config, ProviderNWS)+"/points/"+key, config, nwsStatus, &r); err != nil {
+		return nwsPoint{}, fmt.Errorf("gridpoint lookup for %q failed: %w", config.City, err)
+	}
+	pt = r.Properties
+	if pt.Forecast == "" || pt.ForecastHourly == "" {
+		return nwsPoint{}, fmt.Errorf("no forecast available for %q", config.City)
+	}
+
+	nwsPointMu.Lock()
+	nwsPointCache[key] = pt
+	nwsPointMu.Unlock()
+	return pt, nil
+}
+
+// nwsURL points a URL from a /points response, which always names
+// api.weather.gov, at the baseURL.nws override when one is set.
+func nwsURL(config Config, raw string) string {
+	if config.BaseURLs[ProviderNWS] == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	base, err := url.Parse(baseURL(config, ProviderNWS))
+	if err != nil {
+		return raw
+	}
+	u.Scheme, u.Host = base.Scheme, base.Host
+	u.Path = base.Path + u.Path
+	return u.String()
+}
+
+// nwsAlerts returns the alerts in effect at config.City's coordinates.
+func nwsAlerts(ctx context.Context, config Config) ([]Alert, error) {
+	c, err := geocodeOpenMeteo(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	u := baseURL(config, ProviderNWS) + "/alerts/active?point=" + formatCoord(c.Lat) + "," + formatCoord(c.Lon)
+	var r nwsAlertsResponse
+	if err := getJSON(ctx, u, config, nwsStatus, &r); err != nil {
+		return nil, err
+	}
+	var alerts []Alert
+	for _, f := range r.Features {
+		a := f.Properties
+		if a.MessageType == "Cancel" {
+			continue
+		}
+		headline := a.Headline
+		if headline == "" {
+			headline = a.Event
+		}
+		// ends is when the hazard is over; expires only when the
+		// message is due to be updated.
+		expires := parseAlertTime(a.Ends)
+		if expires == nil {
+			expires = parseAlertTime(a.Expires)
+		}
+		alerts = append(alerts, Alert{
+			Headline:    headline,
+			Event:       a.Event,
+			Severity:    a.Severity,
+			Urgency:     a.Urgency,
+			Area:        a.AreaDesc,
+			Onset:       parseAlertTime(a.Onset),
+			Expires:     expires,
+			Description: a.Description,
+			Instruction: a.Instruction,
+			Source:      ProviderNWS,
+		})
+	}
+	return activeAlerts(alerts, time.Now()), nil
+}
+
+// foldNWSPeriods merges the alternating day and night periods of /forecast
+// into one ForecastDay per date: the daytime period supplies the high and
+// the description, the night period the low. A date with only one of them,
+// such as a first row that starts with "Tonight", has the other marked
+// missing.
+func foldNWSPeriods(periods []nwsPeriod) []ForecastDay {
+	var days []ForecastDay
+	for _, p := range periods {
+		t := p.StartTime
+		date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if len(days) == 0 || !days[len(days)-1].Date.Equal(date) {
+			days = append(days, ForecastDay{Date: date, NoMin: true, NoMax: true})
+		}
+		d := &days[len(days)-1]
+		c, f := nwsTemps(p)
+		if p.IsDaytime {
+			d.MaxTempC, d.MaxTempF, d.NoMax = c, f, false
+			d.Description = strings.TrimSpace(p.ShortForecast)
+			d.Type = nwsWeatherType(p)
+		} else {
+			d.MinTempC, d.MinTempF, d.NoMin = c, f, false
+			if d.NoMax {
+				d.Description = strings.TrimSpace(p.ShortForecast)
+				d.Type = nwsWeatherType(p)
+			}
+		}
+	}
+	return days
+}
+
+func nwsTemps(p nwsPeriod) (c, f float64) {
+	if p.TemperatureUnit == "C" {
+		return p.Temperature, celsiusToFahrenheit(p.Temperature)
+	}
+	return (p.Temperature - 32) * 5 / 9, p.Temperature
+}
+
+func nwsWeatherType(p nwsPeriod) WeatherType {
+	wt := ClassifyWeather(p.ShortForecast)
+	if wt == Sunny && !p.IsDaytime {
+		return ClearNight
+	}
+	return wt
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const ProviderOpenMeteo = "open-meteo"
+
+type openMeteoProvider struct{}
+
+func init() {
+	RegisterProvider(openMeteoProvider{})
+}
+
+type openMeteoGeocodeResponse struct {
+	Results []struct {
+		Name      string  `json:"name"`
+		Latitude  float64 `json:"latitude"`
+		Longitude float64 `json:"longitude"`
+		Country   string  `json:"country"`
+	} `json:"results"`
+}
+
+type openMeteoResponse struct {
+	Current struct {
+		Temperature float64 `json:"temperature_2m"`
+		WeatherCode int     `json:"weather_code"`
+		UVIndex     float64 `json:"uv_index"`
+	} `json:"current"`
+	Daily struct {
+		Time        []string  `json:"time"`
+		WeatherCode []int     `json:"weather_code"`
+		TempMax     []float64 `json:"temperature_2m_max"`
+		TempMin     []float64 `json:"temperature_2m_min"`
+	} `json:"daily"`
+}
+
+func (openMeteoProvider) Name() string { return ProviderOpenMeteo }
+
+func (openMeteoProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 16}
+}
+
+func (openMeteoProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	c, err := geocodeOpenMeteo(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(baseURL(config, ProviderOpenMeteo) + "/forecast")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse open-meteo URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("latitude", formatCoord(c.Lat))
+	q.Set("longitude", formatCoord(c.Lon))
+	q.Set("current", "temperature_2m,weather_code,uv_index")
+	q.Set("timezone", "auto")
+	if config.Forecast > 0 {
+		q.Set("daily", "weather_code,temperature_2m_max,temperature_2m_min")
+		// Open-Meteo rejects more than 16 days outright.
+		q.Set("forecast_days", strconv.Itoa(min(config.Forecast, 16)))
+	}
+	u.RawQuery = q.Encode()
+	return newRequest(ctx, u.String())
+}
+
+func (openMeteoProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+func (openMeteoProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var r openMeteoResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	info := WeatherInfo{
+		Description: wmoDescription(r.Current.WeatherCode),
+		TempC:       r.Current.Temperature,
+		TempF:       celsiusToFahrenheit(r.Current.Temperature),
+		UVIndex:     r.Current.UVIndex,
+		Type:        wmoWeatherType(r.Current.WeatherCode),
+	}
+	if config.Forecast > 0 {
+		daily := r.Daily
+		n := len(daily.Time)
+		if len(daily.WeatherCode) < n || len(daily.TempMax) < n || len(daily.TempMin) < n {
+			return WeatherInfo{}, errors.New("inconsistent daily forecast arrays in response")
+		}
+		for i := 0; i < n && i < config.Forecast; i++ {
+			d, _ := time.Parse("2006-01-02", daily.Time[i])
+			info.Forecast = append(info.Forecast, ForecastDay{
+				Date:        d,
+				MaxTempC:    daily.TempMax[i],
+				MinTempC:    daily.TempMin[i],
+				MaxTempF:    celsiusToFahrenheit(daily.TempMax[i]),
+				MinTempF:    celsiusToFahrenheit(daily.TempMin[i]),
+				Description: wmoDescription(daily.WeatherCode[i]),
+				Type:        wmoWeatherType(daily.WeatherCode[i]),
+			})
+		}
+	}
+	return info, nil
+}
+
+// geocodeOpenMeteo resolves config.City through Open-Meteo's geocoding API.
+func geocodeOpenMeteo(ctx context.Context, config Config) (coords, error) {
+	return cachedCoords(ProviderOpenMeteo, config.City, func() (coords, error) {
+		u, err := url.Parse(baseURL(config, geocodingOpenMeteo) + "/search")
+		if err != nil {
+			return coords{}, fmt.Errorf("failed to parse geocoding URL: %w", err)
+		}
+		q := u.Query()
+		q.Set("name", config.City)
+		q.Set("count", "1")
+		q.Set("format", "json")
+		u.RawQuery = q.Encode()
+
+		var r openMeteoGeocodeResponse
+		if err := getJSON(ctx, u.String(), config, checkOK, &r); err != nil {
+			return coords{}, fmt.Errorf("geocoding %q failed: %w", config.City, err)
+		}
+		if len(r.Results) == 0 {
+			return coords{}, fmt.Errorf("city %q not found", config.City)
+		}
+		return coords{Lat: r.Results[0].Latitude, Lon: r.Results[0].Longitude}, nil
+	})
+}
+
+var wmoDescriptions = map[int]string{
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	56: "Light freezing drizzle",
+	57: "Dense freezing drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	66: "Light freezing rain",
+	67: "Heavy freezing rain",
+	71: "Slight snow fall",
+	73: "Moderate snow fall",
+	75: "Heavy snow fall",
+	77: "Snow grains",
+	80: "Slight rain showers",
+	81: "Moderate rain showers",
+	82: "Violent rain showers",
+	85: "Slight snow showers",
+	86: "Heavy snow showers",
+	95: "Thunderstorm",
+	96: "Thunderstorm with slight hail",
+	99: "Thunderstorm with heavy hail",
+}
+
+func wmoDescription(code int) string {
+	if desc, ok := wmoDescriptions[code]; ok {
+		return desc
+	}
+	return "Unknown"
+}
+
+func wmoWeatherType(code int) WeatherType {
+	switch {
+	case code <= 1:
+		return Sunny
+	case code <= 3:
+		return Cloudy
+	case code == 45, code == 48:
+		return Foggy
+	case code >= 51 && code <= 67, code >= 80 && code <= 82:
+		return Rainy
+	case code >= 71 && code <= 77, code == 85, code == 86:
+		return Snowy
+	case code >= 95 && code <= 99:
+		return Stormy
+	default:
+		return Unknown
+	}
+}
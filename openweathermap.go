@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const ProviderOpenWeatherMap = "openweathermap"
+
+type openWeatherMapProvider struct{}
+
+func init() {
+	RegisterProvider(openWeatherMapProvider{})
+}
+
+type owmCondition struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+}
+
+type owmGeocodeResponse []struct {
+	Name    string  `json:"name"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Country string  `json:"country"`
+}
+
+type owmOneCallResponse struct {
+	TimezoneOffset int64 `json:"timezone_offset"`
+	Current        struct {
+		Temp    float64        `json:"temp"`
+		UVI     float64        `json:"uvi"`
+		Weather []owmCondition `json:"weather"`
+	} `json:"current"`
+	Daily []struct {
+		Dt   int64 `json:"dt"`
+		Temp struct {
+			Min float64 `json:"min"`
+			Max float64 `json:"max"`
+		} `json:"temp"`
+		Weather []owmCondition `json:"weather"`
+	} `json:"daily"`
+}
+
+type owmErrorResponse struct {
+	Message string `json:"message"`
+}
+
+func (openWeatherMapProvider) Name() string { return ProviderOpenWeatherMap }
+
+func (openWeatherMapProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 8, NeedsAPIKey: true}
+}
+
+func (openWeatherMapProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	c, err := geocodeOWM(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(baseURL(config, ProviderOpenWeatherMap) + "/data/3.0/onecall")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenWeatherMap URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("lat", formatCoord(c.Lat))
+	q.Set("lon", formatCoord(c.Lon))
+	q.Set("appid", apiKeyFor(config, ProviderOpenWeatherMap))
+	q.Set("units", "metric")
+	exclude := "minutely,hourly,alerts"
+	if config.Forecast == 0 {
+		exclude += ",daily"
+	}
+	q.Set("exclude", exclude)
+	u.RawQuery = q.Encode()
+	return newRequest(ctx, u.String())
+}
+
+func (openWeatherMapProvider) CheckStatus(resp *http.Response) error {
+	return owmStatus(resp)
+}
+
+// owmStatus classifies an OpenWeatherMap response. OWM puts a human-readable
+// reason in the "message" field of its error bodies, which is more useful
+// than the bare status code.
+func owmStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var e owmErrorResponse
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	_ = json.Unmarshal(body, &e)
+	msg := strings.TrimSpace(e.Message)
+
+	var base string
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		base = "unauthorized: invalid API key or One Call subscription missing"
+	case http.StatusNotFound:
+		base = "not found: location unknown to OpenWeatherMap"
+	case http.StatusTooManyRequests:
+		base = "rate limited: OpenWeatherMap call quota exceeded"
+	default:
+		if msg != "" {
+			return fmt.Errorf("unexpected HTTP status: %s (%s)", resp.Status, msg)
+		}
+		return unexpectedStatus(resp)
+	}
+	if msg != "" {
+		return fmt.Errorf("%s (%s)", base, msg)
+	}
+	return errors.New(base)
+}
+
+func (openWeatherMapProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var r owmOneCallResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	if len(r.Current.Weather) == 0 {
+		return WeatherInfo{}, errors.New("no weather description in response")
+	}
+	cond := r.Current.Weather[0]
+	info := WeatherInfo{
+		Description: owmDescription(cond.Description),
+		TempC:       r.Current.Temp,
+		TempF:       celsiusToFahrenheit(r.Current.Temp),
+		UVIndex:     r.Current.UVI,
+		Type:        owmWeatherType(cond.ID),
+	}
+	if config.Forecast > 0 {
+		for _, day := range r.Daily {
+			if len(info.Forecast) == config.Forecast {
+				break
+			}
+			var desc string
+			wt := Unknown
+			if len(day.Weather) > 0 {
+				desc = owmDescription(day.Weather[0].Description)
+				wt = owmWeatherType(day.Weather[0].ID)
+			}
+			local := time.Unix(day.Dt+r.TimezoneOffset, 0).UTC()
+			info.Forecast = append(info.Forecast, ForecastDay{
+				Date:        time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC),
+				MaxTempC:    day.Temp.Max,
+				MinTempC:    day.Temp.Min,
+				MaxTempF:    celsiusToFahrenheit(day.Temp.Max),
+				MinTempF:    celsiusToFahrenheit(day.Temp.Min),
+				Description: desc,
+				Type:        wt,
+			})
+		}
+	}
+	return info, nil
+}
+
+func geocodeOWM(ctx context.Context, config Config) (coords, error) {
+	return cachedCoords(ProviderOpenWeatherMap, config.City, func() (coords, error) {
+		u, err := url.Parse(baseURL(config, ProviderOpenWeatherMap) + "/geo/1.0/direct")
+		if err != nil {
+			return coords{}, fmt.Errorf("failed to parse geocoding URL: %w", err)
+		}
+		q := u.Query()
+		q.Set("q", config.City)
+		q.Set("limit", "1")
+		q.Set("appid", apiKeyFor(config, ProviderOpenWeatherMap))
+		u.RawQuery = q.Encode()
+
+		var r owmGeocodeResponse
+		if err := getJSON(ctx, u.String(), config, owmStatus, &r); err != nil {
+			return coords{}, fmt.Errorf("geocoding %q failed: %w", config.City, err)
+		}
+		if len(r) == 0 {
+			return coords{}, fmt.Errorf("city %q not found", config.City)
+		}
+		return coords{Lat: r[0].Lat, Lon: r[0].Lon}, nil
+	})
+}
+
+// owmDescription capitalizes OWM's lower-case condition text so it reads
+// like the other providers' descriptions.
+func owmDescription(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// owmWeatherType maps an OpenWeatherMap condition ID onto a WeatherType.
+// See https://openweathermap.org/weather-conditions for the groups.
+func owmWeatherType(id int) WeatherType {
+	switch {
+	case id >= 200 && id < 300:
+		return Stormy
+	case id >= 300 && id < 400, id >= 500 && id < 600:
+		return Rainy
+	case id >= 600 && id < 700:
+		return Snowy
+	case id == 781:
+		return Stormy
+	case id >= 700 && id < 800:
+		return Foggy
+	case id == 800:
+		return Sunny
+	case id > 800 && id < 900:
+		return Cloudy
+	default:
+		return Unknown
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
 	"time"
@@ -23,12 +24,74 @@ const (
 )
 
 const (
-	forecastDayW  = 7
-	forecastDateW = 12
-	forecastCondW = 24
-	forecastTempW = 8
+	forecastDayW    = 7
+	forecastDateW   = 13
+	forecastCondW   = 24
+	forecastTempW   = 9
+	forecastSpreadW = 14
+
+	hourlyTimeW = 11
+	hourlyRainW = 6
+	hourlyWindW = 14
 )
 
+// forecastColumn is an optional forecast table column filled from
+// DayDetails; see Config.ForecastColumns.
+type forecastColumn struct {
+	name   string
+	header string
+	width  int
+	value  func(d *DayDetails, unit string) string
+}
+
+var forecastColumns = []forecastColumn{
+	{"rain", " Rain", 6, func(d *DayDetails, _ string) string { return fmt.Sprintf("%d%%", d.ChanceOfRain) }},
+	{"snow", " Snow", 6, func(d *DayDetails, _ string) string { return fmt.Sprintf("%d%%", d.ChanceOfSnow) }},
+	{"precip", " Precip", 9, func(d *DayDetails, unit string) string { return formatPrecip(d.PrecipMM, d.PrecipIn, unit) }},
+	{"wind", " Wind", 10, func(d *DayDetails, unit string) string {
+		if unit == UnitImperial {
+			return fmt.Sprintf("%.0f mph", d.MaxWindMph)
+		}
+		return fmt.Sprintf("%.0f km/h", d.MaxWindKph)
+	}},
+	{"humidity", " Humid", 7, func(d *DayDetails, _ string) string { return fmt.Sprintf("%d%%", d.AvgHumidity) }},
+	{"uv", " UV", 6, func(d *DayDetails, _ string) string { return formatUV(d.UVIndex) }},
+	{"sunrise", " Sunrise", 9, func(d *DayDetails, _ string) string { return orDash(d.Sunrise) }},
+	{"sunset", " Sunset", 9, func(d *DayDetails, _ string) string { return orDash(d.Sunset) }},
+	{"moonrise", " Moonrise", 10, func(d *DayDetails, _ string) string { return orDash(d.Moonrise) }},
+}
+
+// defaultForecastColumns are shown when forecastColumns is not configured.
+var defaultForecastColumns = []string{"rain", "precip", "wind"}
+
+func lookupForecastColumn(name string) (forecastColumn, bool) {
+	for _, c := range forecastColumns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return forecastColumn{}, false
+}
+
+func forecastColumnNames() []string {
+	names := make([]string, len(forecastColumns))
+	for i, c := range forecastColumns {
+		names[i] = c.name
+	}
+	return names
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// spreadWarnC is how far apart (in °C) providers may be before a spread is
+// highlighted.
+const spreadWarnC = 3.0
+
 func truncate(s string, maxRunes int) string {
 	runes := []rune(s)
 	if len(runes) <= maxRunes {
@@ -52,17 +115,46 @@ func Display(w io.Writer, info WeatherInfo, config Config) {
 		renderJSON(w, info)
 		return
 	}
+	if info.Stale != nil {
+		renderStale(w, info.Stale, config)
+	}
+	if len(info.Alerts) > 0 && !config.NoAlerts {
+		renderAlertBanner(w, info.Alerts, config)
+	}
 	if config.Art {
 		renderArt(w, info, config)
 		return
 	}
+	if info.Aviation != nil {
+		renderAviation(w, info, config)
+		return
+	}
+	if len(info.Hourly) > 0 {
+		renderHourly(w, info, config)
+	}
 	if len(info.Forecast) > 0 {
 		renderForecast(w, info, config)
 		return
 	}
+	if len(info.Hourly) > 0 {
+		fmt.Fprintln(w)
+		return
+	}
 	renderCurrent(w, info, config)
 }
 
+// renderStale warns that the report below came from the cache.
+func renderStale(w io.Writer, s *Staleness, config Config) {
+	msg := fmt.Sprintf("Stale: cached %s ago, fetch failed", formatAge(time.Duration(s.AgeSeconds)*time.Second))
+	if config.Fancy {
+		msg = "⚠️  " + msg
+	}
+	if useColor(config) {
+		msg = Yellow + msg + Reset
+	}
+	fmt.Fprintln(w, msg)
+}
+
 func renderJSON(w io.Writer, info WeatherInfo) {
 	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
@@ -78,17 +170,62 @@ func renderCurrent(w io.Writer, info WeatherInfo, config Config) {
 	if config.Fancy {
 		emoji = WeatherEmoji(info.Type) + " "
 	}
-	fmt.Fprintf(w, "%s%sWeather: %s, %s, UVIndex %s%s\n",
+	fmt.Fprintf(w, "%s%sWeather: %s, %s, UVIndex %s%s%s\n",
 		color, emoji,
 		formatTemp(info.TempC, info.TempF, config.Unit),
 		info.Description,
 		formatUV(info.UVIndex),
+		formatConsensus(info.Consensus, config.Unit),
 		reset,
 	)
+	for _, l := range detailLines(info.Details, config.Unit) {
+		fmt.Fprintf(w, "  %s %s\n", padRight(l.label+":", 12), l.value)
+	}
+}
+
+// detailLines lists the extra current conditions in display order; nil
+// details yield none.
+func detailLines(d *Details, unit string) []struct{ label, value string } {
+	if d == nil {
+		return nil
+	}
+	return []struct{ label, value string }{
+		{"Feels like", formatTemp(d.FeelsLikeC, d.FeelsLikeF, unit)},
+		{"Wind", formatSurfaceWind(d, unit)},
+		{"Humidity", fmt.Sprintf("%d%%", d.Humidity)},
+		{"Pressure", formatPressure(d, unit)},
+		{"Visibility", formatDistance(d.VisibilityKm, d.VisibilityMiles, unit)},
+		{"Cloud cover", fmt.Sprintf("%d%%", d.CloudCover)},
+		{"Precip", formatPrecip(d.PrecipMM, d.PrecipIn, unit)},
+	}
+}
+
+func formatConsensus(c *Consensus, unit string) string {
+	if c == nil {
+		return ""
+	}
+	return fmt.Sprintf(" (spread %s across %d providers)", formatTemp(c.TempSpreadC, c.TempSpreadF, unit), len(c.Providers))
 }
 
 func renderForecast(w io.Writer, info WeatherInfo, config Config) {
-	top, mid, bot := forecastBorders()
+	widths := []int{forecastDayW, forecastDateW, forecastCondW, forecastTempW, forecastTempW}
+	headers := []string{" Day", " Date", " Conditions", " Min", " Max"}
+	showSpread := hasSpread(info.Forecast)
+	if showSpread {
+		widths = append(widths, forecastSpreadW)
+		headers = append(headers, " Spread")
+	}
+	var columns []forecastColumn
+	if hasDayDetails(info.Forecast) {
+		for _, name := range config.ForecastColumns {
+			if c, ok := lookupForecastColumn(name); ok {
+				columns = append(columns, c)
+				widths = append(widths, c.width)
+				headers = append(headers, c.header)
+			}
+		}
+	}
+	top, mid, bot := tableBorders(widths)
 	indent := ""
 	color := useColor(config)
 	if config.Fancy {
@@ -105,12 +242,10 @@ func renderForecast(w io.Writer, info WeatherInfo, config Config) {
 	}
 
 	fmt.Fprintln(w, indent+top)
-	fmt.Fprintf(w, indent+"│%s│%s│%s│%s│%s│\n",
-		padRight(" Day", forecastDayW),
-		padRight(" Date", forecastDateW),
-		padRight(" Conditions", forecastCondW),
-		padRight(" Min", forecastTempW),
-		padRight(" Max", forecastTempW))
+	for i := range headers {
+		headers[i] = padRight(headers[i], widths[i])
+	}
+	fmt.Fprintln(w, indent+tableRow(headers))
 	fmt.Fprintln(w, indent+mid)
 
 	limit := len(info.Forecast)
@@ -121,27 +256,269 @@ func renderForecast(w io.Writer, info WeatherInfo, config Config) {
 		d := info.Forecast[i]
 		dayLabel := fmt.Sprintf(" Day %d", i+1)
 		dateCell := padRight(" "+formatForecastDate(d.Date), forecastDateW)
-		condCell := padRight(truncate(d.Description, forecastCondW), forecastCondW)
-		minCell := padRight(" "+formatTemp(d.MinTempC, d.MinTempF, config.Unit), forecastTempW)
-		maxCell := padRight(" "+formatTemp(d.MaxTempC, d.MaxTempF, config.Unit), forecastTempW)
+		condCell := padRight(" "+truncate(d.Description, forecastCondW-1), forecastCondW)
+		minCell := padRight(" "+formatDayTemp(d.MinTempC, d.MinTempF, d.NoMin, config.Unit), forecastTempW)
+		maxCell := padRight(" "+formatDayTemp(d.MaxTempC, d.MaxTempF, d.NoMax, config.Unit), forecastTempW)
 
+		cells := []string{padRight(dayLabel, forecastDayW), dateCell, condCell, minCell, maxCell}
 		if color {
 			rowColor := WeatherColor(d.Type)
-			fmt.Fprintf(w, indent+"│%s%s%s│%s│%s%s%s│%s│%s│\n",
-				rowColor, padRight(dayLabel, forecastDayW), Reset,
-				dateCell,
-				rowColor, condCell, Reset,
-				minCell, maxCell)
-		} else {
-			fmt.Fprintf(w, indent+"│%s│%s│%s│%s│%s│\n",
-				padRight(dayLabel, forecastDayW), dateCell, condCell, minCell, maxCell)
+			cells[0] = rowColor + cells[0] + Reset
+			cells[2] = rowColor + cells[2] + Reset
+		}
+		if showSpread {
+			spreadCell := padRight(" "+formatSpread(d.Spread, config.Unit), forecastSpreadW)
+			if color && spreadExceeds(d.Spread) {
+				spreadCell = Red + spreadCell + Reset
+			}
+			cells = append(cells, spreadCell)
+		}
+		for _, c := range columns {
+			text := "-"
+			if d.Details != nil {
+				text = c.value(d.Details, config.Unit)
+			}
+			cells = append(cells, padRight(" "+text, c.width))
 		}
+		fmt.Fprintln(w, indent+tableRow(cells))
 	}
 
 	fmt.Fprintln(w, indent+bot)
 	fmt.Fprintln(w)
 }
 
+func renderHourly(w io.Writer, info WeatherInfo, config Config) {
+	widths := []int{hourlyTimeW, forecastCondW, forecastTempW, hourlyRainW}
+	headers := []string{" Time", " Conditions", " Temp", " Rain"}
+	showSnow := false
+	for _, h := range info.Hourly {
+		if h.ChanceOfSnow > 0 {
+			showSnow = true
+		}
+	}
+	if showSnow {
+		widths = append(widths, hourlyRainW)
+		headers = append(headers, " Snow")
+	}
+	widths = append(widths, hourlyWindW)
+	headers = append(headers, " Wind")
+	top, mid, bot := tableBorders(widths)
+	color := useColor(config)
+
+	indent := ""
+	fmt.Fprintln(w)
+	if config.Fancy {
+		indent = "  "
+		header := "  🕒 Hourly"
+		if color {
+			header = "  " + Bold + "🕒 Hourly" + Reset
+		}
+		fmt.Fprintln(w, header)
+	} else {
+		fmt.Fprintln(w, "Hourly")
+	}
+
+	fmt.Fprintln(w, indent+top)
+	for i := range headers {
+		headers[i] = padRight(headers[i], widths[i])
+	}
+	fmt.Fprintln(w, indent+tableRow(headers))
+	fmt.Fprintln(w, indent+mid)
+	for _, h := range info.Hourly {
+		wind := h.WindKph
+		unit := "km/h"
+		if config.Unit == UnitImperial {
+			wind, unit = h.WindMph, "mph"
+		}
+		windText := "Calm"
+		if math.Round(wind) > 0 {
+			windText = strings.TrimSpace(fmt.Sprintf("%s %.0f %s", h.WindDir, wind, unit))
+		}
+		cells := []string{
+			padRight(" "+h.Time.Format("Mon 15:04"), hourlyTimeW),
+			padRight(" "+truncate(h.Description, forecastCondW-1), forecastCondW),
+			padRight(" "+formatTemp(h.TempC, h.TempF, config.Unit), forecastTempW),
+			padRight(fmt.Sprintf(" %d%%", h.ChanceOfRain), hourlyRainW),
+		}
+		if showSnow {
+			cells = append(cells, padRight(fmt.Sprintf(" %d%%", h.ChanceOfSnow), hourlyRainW))
+		}
+		cells = append(cells, padRight(" "+windText, hourlyWindW))
+		if color {
+			cells[1] = WeatherColor(h.Type) + cells[1] + Reset
+		}
+		fmt.Fprintln(w, indent+tableRow(cells))
+	}
+	fmt.Fprintln(w, indent+bot)
+}
+
+const (
+	tafTimeW   = 11
+	tafChangeW = 13
+	tafWindW   = 16
+	tafVisW    = 9
+	tafCeilW   = 9
+	tafCatW    = 6
+	tafWxW     = 22
+)
+
+func renderAviation(w io.Writer, info WeatherInfo, config Config) {
+	av := info.Aviation
+	color := useColor(config)
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%s  observed %s  %s\n", av.Station, av.Observed.Format("Mon 15:04Z"), colorCategory(av.Category, color))
+	fmt.Fprintf(w, "  Conditions:  %s\n", info.Description)
+	fmt.Fprintf(w, "  Temperature: %s\n", formatTemp(info.TempC, info.TempF, config.Unit))
+	fmt.Fprintf(w, "  Wind:        %s\n", formatWind(av.FlightConditions))
+	fmt.Fprintf(w, "  Visibility:  %s (%.1f SM)\n", formatVisibility(av.VisibilityM), av.VisibilitySM)
+	fmt.Fprintf(w, "  Clouds:      %s\n", formatClouds(av.FlightConditions))
+	fmt.Fprintf(w, "  %s\n", av.Raw)
+
+	if len(av.Periods) == 0 {
+		fmt.Fprintln(w)
+		return
+	}
+
+	widths := []int{tafTimeW, tafTimeW, tafChangeW, tafWindW, tafVisW, tafCeilW, tafCatW, tafWxW}
+	headers := []string{" From", " To", " Change", " Wind", " Vis", " Ceiling", " Cat", " Weather"}
+	for i := range headers {
+		headers[i] = padRight(headers[i], widths[i])
+	}
+	top, mid, bot := tableBorders(widths)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "TAF")
+	fmt.Fprintln(w, top)
+	fmt.Fprintln(w, tableRow(headers))
+	fmt.Fprintln(w, mid)
+	for _, p := range av.Periods {
+		ceiling := "-"
+		if p.CeilingFt > 0 {
+			ceiling = fmt.Sprintf("%d ft", p.CeilingFt)
+		}
+		cells := []string{
+			padRight(" "+p.From.Format("Mon 15:04"), tafTimeW),
+			padRight(" "+p.To.Format("Mon 15:04"), tafTimeW),
+			padRight(" "+p.Change, tafChangeW),
+			padRight(" "+formatWind(p.FlightConditions), tafWindW),
+			padRight(" "+formatVisibility(p.VisibilityM), tafVisW),
+			padRight(" "+ceiling, tafCeilW),
+			padRight(" "+p.Category, tafCatW),
+			padRight(" "+describeConditions(p.FlightConditions), tafWxW),
+		}
+		if color {
+			cells[6] = categoryColor(p.Category) + cells[6] + Reset
+		}
+		fmt.Fprintln(w, tableRow(cells))
+	}
+	fmt.Fprintln(w, bot)
+	fmt.Fprintln(w)
+}
+
+func formatWind(fc FlightConditions) string {
+	if fc.WindKt == 0 {
+		return "Calm"
+	}
+	dir := fmt.Sprintf("%03d°", fc.WindDirDeg)
+	if fc.WindVariable {
+		dir = "VRB"
+	}
+	s := fmt.Sprintf("%s %.0f kt", dir, fc.WindKt)
+	if fc.GustKt > 0 {
+		s += fmt.Sprintf(" G%.0f", fc.GustKt)
+	}
+	return s
+}
+
+func formatVisibility(m float64) string {
+	switch {
+	case m >= unlimitedVisM:
+		return "10+ km"
+	case m >= 5000:
+		return fmt.Sprintf("%.0f km", m/1000)
+	default:
+		return fmt.Sprintf("%.0f m", m)
+	}
+}
+
+func formatClouds(fc FlightConditions) string {
+	if len(fc.Clouds) == 0 {
+		return "None reported"
+	}
+	var layers []string
+	for _, l := range fc.Clouds {
+		layers = append(layers, fmt.Sprintf("%s%03d%s", l.Cover, l.BaseFt/100, l.Type))
+	}
+	s := strings.Join(layers, " ")
+	if fc.CeilingFt > 0 {
+		s += fmt.Sprintf(" (ceiling %d ft)", fc.CeilingFt)
+	}
+	return s
+}
+
+func colorCategory(cat string, color bool) string {
+	if !color {
+		return cat
+	}
+	return Bold + categoryColor(cat) + cat + Reset
+}
+
+// categoryColor follows the usual aviation chart colors.
+func categoryColor(cat string) string {
+	switch cat {
+	case FlightVFR:
+		return Green
+	case FlightMVFR:
+		return Blue
+	case FlightIFR:
+		return Red
+	case FlightLIFR:
+		return Magenta
+	default:
+		return ""
+	}
+}
+
+func hasDayDetails(days []ForecastDay) bool {
+	for _, d := range days {
+		if d.Details != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSpread(days []ForecastDay) bool {
+	for _, d := range days {
+		if d.Spread != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// formatSpread renders the min/max temperature spread of a merged day, with
+// a trailing "*" when the providers disagreed on the conditions.
+func formatSpread(s *Spread, unit string) string {
+	if s == nil {
+		return ""
+	}
+	lo, hi := s.MinTempC, s.MaxTempC
+	if unit == UnitImperial {
+		lo, hi = s.MinTempF, s.MaxTempF
+	}
+	out := fmt.Sprintf("%.1f°/%.1f°", lo, hi)
+	if s.Agreement < 1 {
+		out += " *"
+	}
+	return out
+}
+
+func spreadExceeds(s *Spread) bool {
+	return s != nil && (s.MinTempC > spreadWarnC || s.MaxTempC > spreadWarnC)
+}
+
 func formatForecastDate(t time.Time) string {
 	if t.IsZero() {
 		return ""
@@ -156,17 +533,99 @@ func formatTemp(c, f float64, unit string) string {
 	return fmt.Sprintf("%.1f°C", c)
 }
 
+// formatDayTemp is formatTemp for a forecast low or high that may be
+// missing.
+func formatDayTemp(c, f float64, missing bool, unit string) string {
+	if missing {
+		return "-"
+	}
+	return formatTemp(c, f, unit)
+}
+
+// formatTempRange renders e.g. "8/14°C", with "?" for a missing low or
+// high.
+func formatTempRange(d ForecastDay, unit string) string {
+	lo, hi, u := d.MinTempC, d.MaxTempC, "°C"
+	if unit == UnitImperial {
+		lo, hi, u = d.MinTempF, d.MaxTempF, "°F"
+	}
+	loText, hiText := fmt.Sprintf("%.0f", lo), fmt.Sprintf("%.0f", hi)
+	if d.NoMin {
+		loText = "?"
+	}
+	if d.NoMax {
+		hiText = "?"
+	}
+	return loText + "/" + hiText + u
+}
+
+// formatSurfaceWind renders e.g. "NW 50 km/h, gusts 70 km/h".
+func formatSurfaceWind(d *Details, unit string) string {
+	speed, gust, u := d.WindKph, d.GustKph, "km/h"
+	if unit == UnitImperial {
+		speed, gust, u = d.WindMph, d.GustMph, "mph"
+	}
+	if math.Round(speed) == 0 {
+		return "Calm"
+	}
+	dir := d.WindDir
+	if dir == "" {
+		dir = compassPoint(d.WindDirDeg)
+	}
+	s := fmt.Sprintf("%s %.0f %s", dir, speed, u)
+	if gust > speed {
+		s += fmt.Sprintf(", gusts %.0f %s", gust, u)
+	}
+	return s
+}
+
+// compassPoint names the 16-point compass direction for deg.
+func compassPoint(deg int) string {
+	points := []string{"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE", "S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW"}
+	i := int(math.Round(float64(((deg%360)+360)%360)/22.5)) % 16
+	return points[i]
+}
+
+func formatPressure(d *Details, unit string) string {
+	if unit == UnitImperial {
+		return fmt.Sprintf("%.2f inHg", d.PressureIn)
+	}
+	return fmt.Sprintf("%.0f hPa", d.PressureMb)
+}
+
+func formatDistance(km, miles float64, unit string) string {
+	if unit == UnitImperial {
+		return fmt.Sprintf("%g mi", math.Round(miles*10)/10)
+	}
+	return fmt.Sprintf("%g km", math.Round(km*10)/10)
+}
+
+func formatPrecip(mm, in float64, unit string) string {
+	if unit == UnitImperial {
+		return fmt.Sprintf("%.2f in", in)
+	}
+	return fmt.Sprintf("%.1f mm", mm)
+}
+
 func formatUV(uv float64) string {
 	return fmt.Sprintf("%.1f", uv)
 }
 
-func forecastBorders() (top, mid, bot string) {
-	top = "┌" + strings.Repeat("─", forecastDayW) + "┬" + strings.Repeat("─", forecastDateW) + "┬" + strings.Repeat("─", forecastCondW) + "┬" + strings.Repeat("─", forecastTempW) + "┬" + strings.Repeat("─", forecastTempW) + "┐"
-	mid = "├" + strings.Repeat("─", forecastDayW) + "┼" + strings.Repeat("─", forecastDateW) + "┼" + strings.Repeat("─", forecastCondW) + "┼" + strings.Repeat("─", forecastTempW) + "┼" + strings.Repeat("─", forecastTempW) + "┤"
-	bot = "└" + strings.Repeat("─", forecastDayW) + "┴" + strings.Repeat("─", forecastDateW) + "┴" + strings.Repeat("─", forecastCondW) + "┴" + strings.Repeat("─", forecastTempW) + "┴" + strings.Repeat("─", forecastTempW) + "┘"
+func tableBorders(widths []int) (top, mid, bot string) {
+	rules := make([]string, len(widths))
+	for i, w := range widths {
+		rules[i] = strings.Repeat("─", w)
+	}
+	top = "┌" + strings.Join(rules, "┬") + "┐"
+	mid = "├" + strings.Join(rules, "┼") + "┤"
+	bot = "└" + strings.Join(rules, "┴") + "┘"
 	return
 }
 
+func tableRow(cells []string) string {
+	return "│" + strings.Join(cells, "│") + "│"
+}
+
 func useColor(config Config) bool {
 	if !config.Fancy {
 		return false
@@ -205,6 +664,8 @@ func WeatherColor(wt WeatherType) string {
 		return Magenta
 	case Foggy:
 		return Gray
+	case ClearNight:
+		return Blue
 	default:
 		return Green
 	}
@@ -224,6 +685,8 @@ func WeatherEmoji(wt WeatherType) string {
 		return "⛈️"
 	case Foggy:
 		return "🌫️"
+	case ClearNight:
+		return "🌙"
 	default:
 		return "🌈"
 	}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Provider is a weather source. FetchWeather drives it in three steps:
+// build the request, classify the response status, then parse the body.
+type Provider interface {
+	Name() string
+	Capabilities() Capabilities
+	BuildRequest(ctx context.Context, config Config) (*http.Request, error)
+	CheckStatus(resp *http.Response) error
+	Parse(body []byte, config Config) (WeatherInfo, error)
+}
+
+// Fetcher is implemented by providers whose report spans more than one
+// endpoint. FetchWeather hands the whole exchange to Fetch instead of
+// running the BuildRequest/CheckStatus/Parse cycle itself.
+type Fetcher interface {
+	Fetch(ctx context.Context, config Config) (WeatherInfo, error)
+}
+
+// Validator is implemented by providers that need more setup than an API
+// key. GetConfig rejects a chain naming a provider whose Validate fails, and
+// consensus and compare skip it.
+type Validator interface {
+	Validate(config Config) error
+}
+
+// Capabilities describes the limits of a provider. A MaxForecastDays of 0
+// means the provider does not cap the forecast length. Local providers
+// produce reports without the network and bypass the response cache;
+// consensus and compare only use them when the chain names them.
+type Capabilities struct {
+	MaxForecastDays int
+	// MaxHourlyHours is how far ahead -hourly can look; 0 means the
+	// provider has no hourly forecast.
+	MaxHourlyHours int
+	NeedsAPIKey    bool
+	Local          bool
+	// Alerts is set for providers that report severe weather alerts, so
+	// an empty list from them means there are none.
+	Alerts bool
+}
+
+var providers = map[string]Provider{}
+
+func RegisterProvider(p Provider) {
+	providers[p.Name()] = p
+}
+
+// LookupProvider finds a registered provider by name. Names starting with
+// "exec:" resolve to an external program instead.
+func LookupProvider(name string) (Provider, bool) {
+	if path, ok := strings.CutPrefix(name, execPrefix); ok {
+		return execProvider{path: path}, true
+	}
+	p, ok := providers[name]
+	return p, ok
+}
+
+func ProviderNames() []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func providerList() string {
+	names := ProviderNames()
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = `"` + name + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func newRequest(ctx context.Context, urlStr string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+	return req, nil
+}
+
+func checkOK(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return unexpectedStatus(resp)
+	}
+	return nil
+}
+
+// getJSON performs an auxiliary GET (geocoding, metadata lookups) on behalf
+// of a provider, classifies the status with check and decodes the JSON body
+// into v.
+func getJSON(ctx context.Context, urlStr string, config Config, check func(*http.Response) error, v any) error {
+	req, err := newRequest(ctx, urlStr)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	body, err := fetchBody(req, config, check)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	return nil
+}
+
+type coords struct {
+	Lat, Lon float64
+}
+
+var (
+	geocodeMu    sync.Mutex
+	geocodeCache = map[string]coords{}
+)
+
+// cachedCoords memoizes a provider's city lookup for the lifetime of the
+// process so live mode only geocodes once.
+func cachedCoords(provider, city string, resolve func() (coords, error)) (coords, error) {
+	key := provider + "|" + strings.ToLower(strings.TrimSpace(city))
+	geocodeMu.Lock()
+	c, ok := geocodeCache[key]
+	geocodeMu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	c, err := resolve()
+	if err != nil {
+		return coords{}, err
+	}
+
+	geocodeMu.Lock()
+	geocodeCache[key] = c
+	geocodeMu.Unlock()
+	return c, nil
+}
+
+func formatCoord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// secretParams are query parameters redacted from recorded URLs so a
+// recording can be attached to a bug report as-is. They are also left out
+// of the file name, so a replay matches whatever key is configured.
+var secretParams = []string{"key", "apikey", "api_key", "appid", "token", "access_token"}
+
+// recordedResponse is the metadata saved next to each response body.
+type recordedResponse struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header"`
+	Recorded time.Time   `json:"recorded"`
+}
+
+// installRecording wraps httpClient for -record or -replay.
+func installRecording(config Config) error {
+	switch {
+	case config.RecordDir != "":
+		if err := os.MkdirAll(config.RecordDir, 0o755); err != nil {
+			return fmt.Errorf("failed to create record directory: %w", err)
+		}
+		httpClient.Transport = recordTransport{dir: config.RecordDir, next: transportOrDefault(httpClient.Transport)}
+	case config.ReplayDir != "":
+		if _, err := os.Stat(config.ReplayDir); err != nil {
+			return fmt.Errorf("replay directory: %w", err)
+		}
+		httpClient.Transport = replayTransport{dir: config.ReplayDir}
+	}
+	return nil
+}
+
+func transportOrDefault(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		return http.DefaultTransport
+	}
+	return rt
+}
+
+// recordTransport saves every response it passes through. 304 responses
+// are not saved so they never replace the full body they revalidated.
+type recordTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (t recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode == http.StatusNotModified {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	meta := recordedResponse{
+		Method:   req.Method,
+		URL:      redactURL(req.URL),
+		Status:   resp.StatusCode,
+		Header:   resp.Header,
+		Recorded: time.Now().UTC(),
+	}
+	if err := writeRecording(t.dir, meta, body); err != nil {
+		fmt.Fprintln(os.Stderr, "wrep:", err)
+	}
+	return resp, nil
+}
+
+// replayTransport answers from a -record directory and never touches the
+// network.
+type replayTransport struct {
+	dir string
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := filepath.Join(t.dir, recordingName(req.Method, redactURL(req.URL)))
+	metaBytes, err := os.ReadFile(base + ".json")
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, redactURL(req.URL), t.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	var meta recordedResponse
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode recording %s.json: %w", base, err)
+	}
+	body, err := os.ReadFile(base + ".body")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %w", err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", meta.Status, http.StatusText(meta.Status)),
+		StatusCode:    meta.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        meta.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func writeRecording(dir string, meta recordedResponse, body []byte) error {
+	base := filepath.Join(dir, recordingName(meta.Method, meta.URL))
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(meta); err != nil {
+		return fmt.Errorf("failed to encode recording: %w", err)
+	}
+	if err := os.WriteFile(base+".body", body, 0o644); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	if err := os.WriteFile(base+".json", b.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write recording: %w", err)
+	}
+	return nil
+}
+
+// recordingName derives a file name from the redacted URL: a readable
+// host/path prefix plus a hash of the full request line.
+func recordingName(method, redacted string) string {
+	sum := sha256.Sum256([]byte(method + " " + redacted))
+	slug := redacted
+	if u, err := url.Parse(redacted); err == nil {
+		slug = u.Host + u.Path
+	}
+	slug = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, slug)
+	if len(slug) > 60 {
+		slug = slug[:60]
+	}
+	return slug + "-" + hex.EncodeToString(sum[:6])
+}
+
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	changed := false
+	for name := range q {
+		for _, secret := range secretParams {
+			if strings.EqualFold(name, secret) {
+				q.Set(name, "REDACTED")
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	r := *u
+	r.RawQuery = q.Encode()
+	return r.String()
+}
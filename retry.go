@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetries       = 2
+	defaultRetryDelay    = 500 * time.Millisecond
+	defaultRetryMaxDelay = 10 * time.Second
+	// liveMaxBackoff caps how far live mode stretches its interval while
+	// every provider is failing.
+	liveMaxBackoff = 15 * time.Minute
+)
+
+// doWithRetry sends req, retrying network errors and retryable statuses up
+// to config.Retries times. A Retry-After longer than config.RetryMaxDelay is
+// not waited out; the response is returned for the caller to report.
+func doWithRetry(req *http.Request, config Config) (*http.Response, error) {
+	ctx := req.Context()
+	attempts := config.Retries + 1
+	for attempt := 1; ; attempt++ {
+		resp, err := httpClient.Do(req)
+		var reason string
+		var wait time.Duration
+		switch {
+		case err != nil:
+			if ctx.Err() != nil || attempt >= attempts {
+				return nil, err
+			}
+			reason = err.Error()
+			wait = backoffDelay(attempt, config.RetryDelay, config.RetryMaxDelay)
+		case retryableStatus(resp.StatusCode) && attempt < attempts:
+			reason = resp.Status
+			wait = backoffDelay(attempt, config.RetryDelay, config.RetryMaxDelay)
+			if ra, ok := retryAfter(resp, time.Now()); ok {
+				if ra > config.RetryMaxDelay {
+					return resp, nil
+				}
+				wait = ra
+			}
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		default:
+			return resp, nil
+		}
+
+		if config.Verbose && !config.Quiet {
+			fmt.Fprintf(os.Stderr, "wrep: attempt %d/%d for %s failed (%s); retrying in %s\n",
+				attempt, attempts, req.URL, reason, wait.Round(10*time.Millisecond))
+		}
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryableStatus reports whether a response is worth retrying: rate
+// limiting and server-side failures, which usually pass.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses a Retry-After header given either as seconds or as an
+// HTTP date. It returns false when the header is absent or malformed.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0), true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// backoffDelay returns the wait before retry number attempt (starting at 1):
+// base doubled per attempt, capped at limit, with the upper half jittered so
+// concurrent clients don't retry in lockstep.
+func backoffDelay(attempt int, base, limit time.Duration) time.Duration {
+	d := base << (attempt - 1)
+	if d > limit || d <= 0 {
+		d = limit
+	}
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// sleepCtx waits for d or until ctx is done, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// liveDelay stretches the live interval while providers keep failing:
+// doubled per failed tick, capped at liveMaxBackoff (or the interval itself
+// if that is longer).
+func liveDelay(interval time.Duration, failures int) time.Duration {
+	if failures == 0 {
+		return interval
+	}
+	limit := max(interval, liveMaxBackoff)
+	d := interval << failures
+	if d > limit || d <= 0 {
+		return limit
+	}
+	return d
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 18, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{" 5 ", 5 * time.Second, true},
+		{"0", 0, true},
+		{"-3", 0, true},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	const base, limit = 500 * time.Millisecond, 8 * time.Second
+	tests := []struct {
+		attempt int
+		full    time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{4, 4 * time.Second},
+		{5, limit},
+		{80, limit},
+	}
+	for _, tt := range tests {
+		// The delay is jittered within the upper half of the full step.
+		for range 50 {
+			d := backoffDelay(tt.attempt, base, limit)
+			if d < tt.full/2 || d > tt.full {
+				t.Fatalf("backoffDelay(%d) = %s, want %s..%s", tt.attempt, d, tt.full/2, tt.full)
+			}
+		}
+	}
+	if d := backoffDelay(1, time.Nanosecond, limit); d != time.Nanosecond {
+		t.Errorf("backoffDelay with a 1ns base = %s, want 1ns", d)
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	for code, want := range map[int]bool{
+		http.StatusTooManyRequests:     true,
+		http.StatusInternalServerError: true,
+		http.StatusBadGateway:          true,
+		http.StatusServiceUnavailable:  true,
+		http.StatusGatewayTimeout:      true,
+		http.StatusOK:                  false,
+		http.StatusNotFound:            false,
+		http.StatusUnauthorized:        false,
+		http.StatusNotImplemented:      false,
+	} {
+		if got := retryableStatus(code); got != want {
+			t.Errorf("retryableStatus(%d) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestLiveDelay(t *testing.T) {
+	tests := []struct {
+		interval time.Duration
+		failures int
+		want     time.Duration
+	}{
+		{time.Minute, 0, time.Minute},
+		{time.Minute, 1, 2 * time.Minute},
+		{time.Minute, 3, 8 * time.Minute},
+		{time.Minute, 4, liveMaxBackoff},
+		{time.Minute, 70, liveMaxBackoff},
+		{time.Hour, 2, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := liveDelay(tt.interval, tt.failures); got != tt.want {
+			t.Errorf("liveDelay(%s, %d) = %s, want %s", tt.interval, tt.failures, got, tt.want)
+		}
+	}
+}
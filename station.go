@@ -0,0 +1,336 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProviderStation      = "station"
+	defaultStationListen = ":8080"
+	// stationMaxAge is how old the stored observation may be before the
+	// station provider refuses to report it as current.
+	stationMaxAge = 30 * time.Minute
+	wuUploadPath  = "/weatherstation/updateweatherstation.php"
+)
+
+// StationObservation is the latest upload from a personal weather station,
+// normalized to metric units.
+type StationObservation struct {
+	Received       time.Time `json:"received"`
+	Observed       time.Time `json:"observed"`
+	Protocol       string    `json:"protocol"`
+	Model          string    `json:"model,omitempty"`
+	TempC          float64   `json:"temp_c"`
+	TempF          float64   `json:"temp_f"`
+	Humidity       float64   `json:"humidity"`
+	WindDirDeg     float64   `json:"wind_dir_deg"`
+	WindKph        float64   `json:"wind_kph"`
+	GustKph        float64   `json:"gust_kph"`
+	PressureHPa    float64   `json:"pressure_hpa"`
+	RainRateMm     float64   `json:"rain_rate_mm"`
+	UVIndex        float64   `json:"uv_index"`
+	SolarRadiation float64   `json:"solar_radiation"`
+}
+
+type stationProvider struct{}
+
+func init() {
+	RegisterProvider(stationProvider{})
+}
+
+func (stationProvider) Name() string { return ProviderStation }
+
+func (stationProvider) Capabilities() Capabilities {
+	return Capabilities{Local: true}
+}
+
+// The station provider never goes to the network for current conditions,
+// so the request methods only exist to satisfy Provider; Fetch does the
+// work.
+func (stationProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	return nil, errors.New("the station provider reads a local file")
+}
+
+func (stationProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+func (stationProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var obs StationObservation
+	if err := json.Unmarshal(body, &obs); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode station observation: %w", err)
+	}
+	desc, wt := stationConditions(obs)
+	return WeatherInfo{
+		TempC:       obs.TempC,
+		TempF:       obs.TempF,
+		UVIndex:     obs.UVIndex,
+		Description: desc,
+		Type:        wt,
+		Station:     &obs,
+	}, nil
+}
+
+// Fetch reports the stored observation and, for -f, borrows the forecast
+// from config.StationForecast.
+func (p stationProvider) Fetch(ctx context.Context, config Config) (WeatherInfo, error) {
+	path, err := stationFile(config)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	body, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return WeatherInfo{}, fmt.Errorf("no station observation yet (is `wrep station` running?): %s", path)
+	}
+	if err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to read station observation: %w", err)
+	}
+	info, err := p.Parse(body, config)
+	if err != nil {
+		return WeatherInfo{}, err
+	}
+	if age := time.Since(info.Station.Received); age > stationMaxAge {
+		return WeatherInfo{}, fmt.Errorf("station observation is stale (last upload %s ago)", age.Round(time.Minute))
+	}
+
+	if config.Forecast > 0 {
+		fp, ok := LookupProvider(config.StationForecast)
+		if !ok {
+			return WeatherInfo{}, fmt.Errorf("unknown stationForecast provider %q", config.StationForecast)
+		}
+		cfg := config
+		cfg.APIProvider = fp.Name()
+		remote, err := fetchFrom(ctx, fp, cfg)
+		if err != nil {
+			return WeatherInfo{}, fmt.Errorf("forecast from %s failed: %w", fp.Name(), err)
+		}
+		info.Forecast = remote.Forecast
+	}
+	return info, nil
+}
+
+// stationConditions guesses a description from what a station can measure:
+// rain from the rain gauge, sun from the solar radiation sensor.
+func stationConditions(obs StationObservation) (string, WeatherType) {
+	switch {
+	case obs.RainRateMm > 0 && obs.TempC <= 0:
+		return fmt.Sprintf("Precipitation %.1f mm/h", obs.RainRateMm), Snowy
+	case obs.RainRateMm > 0:
+		return fmt.Sprintf("Rain %.1f mm/h", obs.RainRateMm), Rainy
+	case obs.SolarRadiation >= 400:
+		return "Sunny", Sunny
+	case obs.SolarRadiation > 0:
+		return "Cloudy", Cloudy
+	default:
+		return "Dry", Unknown
+	}
+}
+
+// stationFile returns config.StationFile, defaulting to station.json under
+// the user cache directory.
+func stationFile(config Config) (string, error) {
+	if config.StationFile != "" {
+		return config.StationFile, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("could not determine user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "wrep", "station.json"), nil
+}
+
+// runStation listens for uploads from a personal weather station until
+// interrupted, saving each one to config.StationFile.
+func runStation(ctx context.Context, config Config) error {
+	path, err := stationFile(config)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create station directory: %w", err)
+	}
+	config.StationFile = path
+
+	srv := &http.Server{
+		Addr:              config.StationListen,
+		Handler:           stationHandler(config),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	if !config.Quiet {
+		fmt.Fprintf(os.Stderr, "wrep: listening for station uploads on %s (Ctrl+C to stop)\n", config.StationListen)
+		if config.StationPassword == "" && !isLoopbackAddr(config.StationListen) {
+			fmt.Fprintln(os.Stderr, "wrep: no stationPassword set; anyone who can reach this address can overwrite the observation")
+		}
+	}
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("station listener failed: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// isLoopbackAddr reports whether a listen address only accepts connections
+// from this machine. An empty host listens on every interface.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// stationHandler accepts the Weather Underground GET protocol on its usual
+// path and Ecowitt "customized" POST uploads on any other path.
+func stationHandler(config Config) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "malformed upload", http.StatusBadRequest)
+			return
+		}
+
+		var obs StationObservation
+		var err error
+		switch {
+		case r.URL.Path == wuUploadPath:
+			obs, err = parseStationUpload(r.Form, "wunderground", config.StationPassword, "PASSWORD")
+		case r.Method == http.MethodPost:
+			obs, err = parseStationUpload(r.PostForm, "ecowitt", config.StationPassword, "PASSKEY")
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			if config.Verbose && !config.Quiet {
+				fmt.Fprintln(os.Stderr, "wrep: rejected station upload:", err)
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := saveStationObservation(config.StationFile, obs); err != nil {
+			fmt.Fprintln(os.Stderr, "wrep:", err)
+			http.Error(w, "failed to store observation", http.StatusInternalServerError)
+			return
+		}
+		if config.Verbose && !config.Quiet {
+			fmt.Fprintf(os.Stderr, "wrep: %s upload: %.1f°C, %.0f%% humidity\n", obs.Protocol, obs.TempC, obs.Humidity)
+		}
+		// Weather Underground clients look for this exact body.
+		fmt.Fprintln(w, "success")
+	})
+}
+
+type formValues interface {
+	Get(key string) string
+}
+
+// parseStationUpload decodes either push protocol. Both send imperial units
+// with the same field names for the basics; Ecowitt names the pressure and
+// rain rate differently.
+func parseStationUpload(form formValues, protocol, password, passwordField string) (StationObservation, error) {
+	if password != "" && form.Get(passwordField) != password {
+		return StationObservation{}, fmt.Errorf("%s does not match stationPassword", passwordField)
+	}
+	tempF, err := strconv.ParseFloat(form.Get("tempf"), 64)
+	if err != nil {
+		return StationObservation{}, errors.New("missing or invalid tempf")
+	}
+
+	now := time.Now().UTC()
+	obs := StationObservation{
+		Received:       now,
+		Observed:       now,
+		Protocol:       protocol,
+		Model:          firstNonEmpty(form.Get("model"), form.Get("stationtype"), form.Get("softwaretype")),
+		TempF:          tempF,
+		TempC:          (tempF - 32) * 5 / 9,
+		Humidity:       formFloat(form, "humidity"),
+		WindDirDeg:     formFloat(form, "winddir"),
+		WindKph:        formFloat(form, "windspeedmph") * 1.609344,
+		GustKph:        formFloat(form, "windgustmph") * 1.609344,
+		PressureHPa:    firstPositive(formFloat(form, "baromrelin"), formFloat(form, "baromin")) * 33.8639,
+		RainRateMm:     firstPositive(formFloat(form, "rainratein"), formFloat(form, "rainin")) * 25.4,
+		UVIndex:        firstPositive(formFloat(form, "uv"), formFloat(form, "UV")),
+		SolarRadiation: formFloat(form, "solarradiation"),
+	}
+	if s := form.Get("dateutc"); s != "" && s != "now" {
+		if t, err := time.Parse("2006-01-02 15:04:05", s); err == nil {
+			obs.Observed = t
+		}
+	}
+	return obs, nil
+}
+
+// saveStationObservation replaces the stored observation atomically so a
+// concurrent reader never sees a half-written file.
+func saveStationObservation(path string, obs StationObservation) error {
+	b, err := json.MarshalIndent(obs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode observation: %w", err)
+	}
+	// Uploads are handled concurrently, so each gets its own temp file.
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write observation: %w", err)
+	}
+	tmp := f.Name()
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write observation: %w", err)
+	}
+	return nil
+}
+
+func formFloat(form formValues, key string) float64 {
+	f, _ := strconv.ParseFloat(strings.TrimSpace(form.Get(key)), 64)
+	return f
+}
+
+func firstPositive(vals ...float64) float64 {
+	for _, v := range vals {
+		if v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type weatherAPIProvider struct{}
+
+func init() {
+	RegisterProvider(weatherAPIProvider{})
+}
+
+type weatherAPIResponse struct {
+	Location struct {
+		LocalTime string `json:"localtime"`
+	} `json:"location"`
+	Current struct {
+		TempC      float64 `json:"temp_c"`
+		TempF      float64 `json:"temp_f"`
+		FeelsLikeC float64 `json:"feelslike_c"`
+		FeelsLikeF float64 `json:"feelslike_f"`
+		UVIndex    float64 `json:"uv"`
+		WindKph    float64 `json:"wind_kph"`
+		WindMph    float64 `json:"wind_mph"`
+		GustKph    float64 `json:"gust_kph"`
+		GustMph    float64 `json:"gust_mph"`
+		WindDegree int     `json:"wind_degree"`
+		WindDir    string  `json:"wind_dir"`
+		Humidity   int     `json:"humidity"`
+		PressureMb float64 `json:"pressure_mb"`
+		PressureIn float64 `json:"pressure_in"`
+		VisKm      float64 `json:"vis_km"`
+		VisMiles   float64 `json:"vis_miles"`
+		Cloud      int     `json:"cloud"`
+		PrecipMM   float64 `json:"precip_mm"`
+		PrecipIn   float64 `json:"precip_in"`
+		Condition  struct {
+			Text string `json:"text"`
+		} `json:"condition"`
+	} `json:"current"`
+	Forecast struct {
+		ForecastDay []struct {
+			Date string `json:"date"`
+			Day  struct {
+				MaxTempC      float64 `json:"maxtemp_c"`
+				MaxTempF      float64 `json:"maxtemp_f"`
+				MinTempC      float64 `json:"mintemp_c"`
+				MinTempF      float64 `json:"mintemp_f"`
+				MaxWindKph    float64 `json:"maxwind_kph"`
+				MaxWindMph    float64 `json:"maxwind_mph"`
+				TotalPrecipMM float64 `json:"totalprecip_mm"`
+				TotalPrecipIn float64 `json:"totalprecip_in"`
+				AvgHumidity   float64 `json:"avghumidity"`
+				ChanceOfRain  int     `json:"daily_chance_of_rain"`
+				ChanceOfSnow  int     `json:"daily_chance_of_snow"`
+				UV            float64 `json:"uv"`
+				Condition     struct {
+					Text string `json:"text"`
+				} `json:"condition"`
+			} `json:"day"`
+			Astro struct {
+				Sunrise  string `json:"sunrise"`
+				Sunset   string `json:"sunset"`
+				Moonrise string `json:"moonrise"`
+			} `json:"astro"`
+			Hour []struct {
+				Time         string  `json:"time"`
+				TempC        float64 `json:"temp_c"`
+				TempF        float64 `json:"temp_f"`
+				WindKph      float64 `json:"wind_kph"`
+				WindMph      float64 `json:"wind_mph"`
+				WindDir      string  `json:"wind_dir"`
+				ChanceOfRain int     `json:"chance_of_rain"`
+				ChanceOfSnow int     `json:"chance_of_snow"`
+				Condition    struct {
+					Text string `json:"text"`
+				} `json:"condition"`
+			} `json:"hour"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+	Alerts struct {
+		Alert []struct {
+			Headline    string `json:"headline"`
+			MsgType     string `json:"msgtype"`
+			Severity    string `json:"severity"`
+			Urgency     string `json:"urgency"`
+			Areas       string `json:"areas"`
+			Event       string `json:"event"`
+			Effective   string `json:"effective"`
+			Expires     string `json:"expires"`
+			Desc        string `json:"desc"`
+			Instruction string `json:"instruction"`
+		} `json:"alert"`
+	} `json:"alerts"`
+}
+
+func (weatherAPIProvider) Name() string { return ProviderWeatherAPI }
+
+func (weatherAPIProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 14, MaxHourlyHours: 14 * 24, NeedsAPIKey: true, Alerts: true}
+}
+
+func (weatherAPIProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	// Hourly slots come with the forecast days, so -hourly may need more
+	// days than -f asked for.
+	days := max(config.Forecast, weatherAPIHourlyDays(config.Hourly))
+	// Alerts are only part of forecast.json.
+	if !config.NoAlerts {
+		days = max(days, 1)
+	}
+	base := baseURL(config, ProviderWeatherAPI) + "/current.json"
+	if days > 0 {
+		base = baseURL(config, ProviderWeatherAPI) + "/forecast.json"
+	}
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("key", apiKeyFor(config, ProviderWeatherAPI))
+	q.Set("q", config.City)
+	if days > 0 {
+		q.Set("days", strconv.Itoa(min(days, 14)))
+	}
+	if !config.NoAlerts {
+		q.Set("alerts", "yes")
+	}
+	u.RawQuery = q.Encode()
+	return newRequest(ctx, u.String())
+}
+
+func (weatherAPIProvider) CheckStatus(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		return errors.New("unauthorized: invalid or missing API key")
+	case http.StatusBadRequest:
+		return errors.New("bad request: city not provided or invalid")
+	case http.StatusForbidden:
+		return errors.New("forbidden: API access denied or quota exceeded")
+	default:
+		return unexpectedStatus(resp)
+	}
+}
+
+func (weatherAPIProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var r weatherAPIResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	info := WeatherInfo{
+		Description: strings.TrimSpace(r.Current.Condition.Text),
+		TempC:       r.Current.TempC,
+		TempF:       r.Current.TempF,
+		UVIndex:     r.Current.UVIndex,
+		Details: &Details{
+			FeelsLikeC:      r.Current.FeelsLikeC,
+			FeelsLikeF:      r.Current.FeelsLikeF,
+			WindKph:         r.Current.WindKph,
+			WindMph:         r.Current.WindMph,
+			GustKph:         r.Current.GustKph,
+			GustMph:         r.Current.GustMph,
+			WindDirDeg:      r.Current.WindDegree,
+			WindDir:         r.Current.WindDir,
+			Humidity:        r.Current.Humidity,
+			PressureMb:      r.Current.PressureMb,
+			PressureIn:      r.Current.PressureIn,
+			VisibilityKm:    r.Current.VisKm,
+			VisibilityMiles: r.Current.VisMiles,
+			CloudCover:      r.Current.Cloud,
+			PrecipMM:        r.Current.PrecipMM,
+			PrecipIn:        r.Current.PrecipIn,
+		},
+	}
+	info.Type = ClassifyWeather(info.Description)
+	for _, a := range r.Alerts.Alert {
+		if strings.EqualFold(a.MsgType, "Cancel") {
+			continue
+		}
+		headline := a.Headline
+		if headline == "" {
+			headline = a.Event
+		}
+		info.Alerts = append(info.Alerts, Alert{
+			Headline:    headline,
+			Event:       a.Event,
+			Severity:    a.Severity,
+			Urgency:     a.Urgency,
+			Area:        a.Areas,
+			Onset:       parseAlertTime(a.Effective),
+			Expires:     parseAlertTime(a.Expires),
+			Description: a.Desc,
+			Instruction: a.Instruction,
+			Source:      ProviderWeatherAPI,
+		})
+	}
+	info.Alerts = activeAlerts(info.Alerts, time.Now())
+	if config.Hourly > 0 {
+		now, err := time.Parse(weatherAPITimeLayout, r.Location.LocalTime)
+		if err != nil {
+			return WeatherInfo{}, fmt.Errorf("unrecognized local time %q in response", r.Location.LocalTime)
+		}
+		for _, day := range r.Forecast.ForecastDay {
+			for _, h := range day.Hour {
+				t, err := time.Parse(weatherAPITimeLayout, h.Time)
+				if err != nil || !inHourlyWindow(t, time.Hour, now, config.Hourly) {
+					continue
+				}
+				desc := strings.TrimSpace(h.Condition.Text)
+				info.Hourly = append(info.Hourly, ForecastHour{
+					Time:         t,
+					TempC:        h.TempC,
+					TempF:        h.TempF,
+					Description:  desc,
+					Type:         ClassifyWeather(desc),
+					ChanceOfRain: h.ChanceOfRain,
+					ChanceOfSnow: h.ChanceOfSnow,
+					WindKph:      h.WindKph,
+					WindMph:      h.WindMph,
+					WindDir:      h.WindDir,
+				})
+			}
+		}
+	}
+	if config.Forecast > 0 {
+		for i, day := range r.Forecast.ForecastDay {
+			if i >= config.Forecast {
+				break
+			}
+			d, _ := time.Parse("2006-01-02", day.Date)
+			desc := strings.TrimSpace(day.Day.Condition.Text)
+			info.Forecast = append(info.Forecast, ForecastDay{
+				Date:        d,
+				MaxTempC:    day.Day.MaxTempC,
+				MinTempC:    day.Day.MinTempC,
+				MaxTempF:    day.Day.MaxTempF,
+				MinTempF:    day.Day.MinTempF,
+				Description: desc,
+				Type:        ClassifyWeather(desc),
+				Details: &DayDetails{
+					ChanceOfRain: day.Day.ChanceOfRain,
+					ChanceOfSnow: day.Day.ChanceOfSnow,
+					PrecipMM:     day.Day.TotalPrecipMM,
+					PrecipIn:     day.Day.TotalPrecipIn,
+					MaxWindKph:   day.Day.MaxWindKph,
+					MaxWindMph:   day.Day.MaxWindMph,
+					AvgHumidity:  int(math.Round(day.Day.AvgHumidity)),
+					UVIndex:      day.Day.UV,
+					Sunrise:      parseClock(day.Astro.Sunrise),
+					Sunset:       parseClock(day.Astro.Sunset),
+					Moonrise:     parseClock(day.Astro.Moonrise),
+				},
+			})
+		}
+	}
+	return info, nil
+}
+
+// weatherAPITimeLayout is the format of local times such as location.localtime
+// and hour[].time.
+const weatherAPITimeLayout = "2006-01-02 15:04"
+
+// weatherAPIHourlyDays is how many forecast days cover the next hours hours,
+// counting today.
+func weatherAPIHourlyDays(hours int) int {
+	if hours <= 0 {
+		return 0
+	}
+	return hours/24 + 2
+}
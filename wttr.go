@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type wttrProvider struct{}
+
+func init() {
+	RegisterProvider(wttrProvider{})
+}
+
+type wttrInDesc struct {
+	Value string `json:"value"`
+}
+
+// wttrInHour is one 3-hour slot of a wttr.in forecast day. Time is the
+// local start hour times 100 ("0", "300", … "2100").
+type wttrInHour struct {
+	Time           string       `json:"time"`
+	TempC          string       `json:"tempC"`
+	TempF          string       `json:"tempF"`
+	Winddir16Point string       `json:"winddir16Point"`
+	ChanceOfRain   string       `json:"chanceofrain"`
+	ChanceOfSnow   string       `json:"chanceofsnow"`
+	PrecipMM       string       `json:"precipMM"`
+	PrecipInches   string       `json:"precipInches"`
+	Humidity       string       `json:"humidity"`
+	WindspeedKmph  string       `json:"windspeedKmph"`
+	WindspeedMiles string       `json:"windspeedMiles"`
+	WindGustKmph   string       `json:"WindGustKmph"`
+	WindGustMiles  string       `json:"WindGustMiles"`
+	WeatherDesc    []wttrInDesc `json:"weatherDesc"`
+}
+
+type wttrInAstronomy struct {
+	Sunrise  string `json:"sunrise"`
+	Sunset   string `json:"sunset"`
+	Moonrise string `json:"moonrise"`
+}
+
+type wttrInResponse struct {
+	CurrentCondition []struct {
+		TempC           string       `json:"temp_C"`
+		TempF           string       `json:"temp_F"`
+		FeelsLikeC      string       `json:"FeelsLikeC"`
+		FeelsLikeF      string       `json:"FeelsLikeF"`
+		UvIndex         string       `json:"uvIndex"`
+		WindspeedKmph   string       `json:"windspeedKmph"`
+		WindspeedMiles  string       `json:"windspeedMiles"`
+		WinddirDegree   string       `json:"winddirDegree"`
+		Winddir16Point  string       `json:"winddir16Point"`
+		Humidity        string       `json:"humidity"`
+		Pressure        string       `json:"pressure"`
+		PressureInches  string       `json:"pressureInches"`
+		Visibility      string       `json:"visibility"`
+		VisibilityMiles string       `json:"visibilityMiles"`
+		Cloudcover      string       `json:"cloudcover"`
+		PrecipMM        string       `json:"precipMM"`
+		PrecipInches    string       `json:"precipInches"`
+		WeatherDesc     []wttrInDesc `json:"weatherDesc"`
+		ObsTime         string       `json:"localObsDateTime"`
+	} `json:"current_condition"`
+	Weather []struct {
+		Date      string            `json:"date"`
+		MaxTempC  string            `json:"maxtempC"`
+		MaxTempF  string            `json:"maxtempF"`
+		MinTempC  string            `json:"mintempC"`
+		MinTempF  string            `json:"mintempF"`
+		UvIndex   string            `json:"uvIndex"`
+		Astronomy []wttrInAstronomy `json:"astronomy"`
+		Hourly    []wttrInHour      `json:"hourly"`
+	} `json:"weather"`
+}
+
+func (wttrProvider) Name() string { return ProviderWttr }
+
+func (wttrProvider) Capabilities() Capabilities {
+	return Capabilities{MaxForecastDays: 3, MaxHourlyHours: 48}
+}
+
+func (wttrProvider) BuildRequest(ctx context.Context, config Config) (*http.Request, error) {
+	u, err := url.Parse(baseURL(config, ProviderWttr) + "/" + url.PathEscape(config.City))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wttr.in URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("format", "j1")
+	u.RawQuery = q.Encode()
+	return newRequest(ctx, u.String())
+}
+
+func (wttrProvider) CheckStatus(resp *http.Response) error {
+	return checkOK(resp)
+}
+
+func (wttrProvider) Parse(body []byte, config Config) (WeatherInfo, error) {
+	var r wttrInResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return WeatherInfo{}, fmt.Errorf("failed to decode JSON response: %w", err)
+	}
+	if len(r.CurrentCondition) == 0 {
+		return WeatherInfo{}, errors.New("no current condition data in response")
+	}
+	cc := r.CurrentCondition[0]
+	if len(cc.WeatherDesc) == 0 {
+		return WeatherInfo{}, errors.New("no weather description in response")
+	}
+
+	info := WeatherInfo{
+		Description: strings.TrimSpace(cc.WeatherDesc[0].Value),
+		TempC:       parseFloat(cc.TempC),
+		TempF:       parseFloat(cc.TempF),
+		UVIndex:     parseFloat(cc.UvIndex),
+		Details: &Details{
+			FeelsLikeC:      parseFloat(cc.FeelsLikeC),
+			FeelsLikeF:      parseFloat(cc.FeelsLikeF),
+			WindKph:         parseFloat(cc.WindspeedKmph),
+			WindMph:         parseFloat(cc.WindspeedMiles),
+			WindDirDeg:      int(parseFloat(cc.WinddirDegree)),
+			WindDir:         cc.Winddir16Point,
+			Humidity:        int(parseFloat(cc.Humidity)),
+			PressureMb:      parseFloat(cc.Pressure),
+			PressureIn:      parseFloat(cc.PressureInches),
+			VisibilityKm:    parseFloat(cc.Visibility),
+			VisibilityMiles: parseFloat(cc.VisibilityMiles),
+			CloudCover:      int(parseFloat(cc.Cloudcover)),
+			PrecipMM:        parseFloat(cc.PrecipMM),
+			PrecipIn:        parseFloat(cc.PrecipInches),
+		},
+	}
+	info.Type = ClassifyWeather(info.Description)
+	obs, obsErr := time.Parse(wttrObsLayout, cc.ObsTime)
+	if len(r.Weather) > 0 && obsErr == nil {
+		if h, ok := wttrSlotAt(r.Weather[0].Hourly, obs); ok {
+			info.Details.GustKph = parseFloat(h.WindGustKmph)
+			info.Details.GustMph = parseFloat(h.WindGustMiles)
+		}
+	}
+
+	if config.Hourly > 0 && obsErr == nil {
+		for _, day := range r.Weather {
+			date, err := time.Parse("2006-01-02", day.Date)
+			if err != nil {
+				continue
+			}
+			for _, h := range day.Hourly {
+				t := date.Add(wttrSlotStart(h.Time))
+				if !inHourlyWindow(t, 3*time.Hour, obs, config.Hourly) {
+					continue
+				}
+				desc := ""
+				if len(h.WeatherDesc) > 0 {
+					desc = strings.TrimSpace(h.WeatherDesc[0].Value)
+				}
+				info.Hourly = append(info.Hourly, ForecastHour{
+					Time:         t,
+					TempC:        parseFloat(h.TempC),
+					TempF:        parseFloat(h.TempF),
+					Description:  desc,
+					Type:         ClassifyWeather(desc),
+					ChanceOfRain: int(parseFloat(h.ChanceOfRain)),
+					ChanceOfSnow: int(parseFloat(h.ChanceOfSnow)),
+					WindKph:      parseFloat(h.WindspeedKmph),
+					WindMph:      parseFloat(h.WindspeedMiles),
+					WindDir:      h.Winddir16Point,
+				})
+			}
+		}
+	}
+
+	if config.Forecast > 0 {
+		for _, day := range r.Weather {
+			if len(info.Forecast) == config.Forecast {
+				break
+			}
+			desc := representativeWttrDesc(day.Hourly)
+			d, _ := time.Parse("2006-01-02", day.Date)
+			info.Forecast = append(info.Forecast, ForecastDay{
+				Date:        d,
+				MaxTempC:    parseFloat(day.MaxTempC),
+				MinTempC:    parseFloat(day.MinTempC),
+				MaxTempF:    parseFloat(day.MaxTempF),
+				MinTempF:    parseFloat(day.MinTempF),
+				Description: desc,
+				Type:        ClassifyWeather(desc),
+				Details:     wttrDayDetails(day.Hourly, day.Astronomy, parseFloat(day.UvIndex)),
+			})
+		}
+	}
+	return info, nil
+}
+
+// representativeWttrDesc picks the noon entry (3-hour interval, index 4) from
+// wttr.in's hourly slice so the forecast row shows a midday condition rather
+// than midnight. Falls back to whatever's available.
+func representativeWttrDesc(hourly []wttrInHour) string {
+	if len(hourly) == 0 {
+		return ""
+	}
+	idx := 4
+	if idx >= len(hourly) {
+		idx = 0
+	}
+	if len(hourly[idx].WeatherDesc) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(hourly[idx].WeatherDesc[0].Value)
+}
+
+// wttrObsLayout is the format of localObsDateTime, e.g. "2024-05-10 09:12 AM".
+const wttrObsLayout = "2006-01-02 03:04 PM"
+
+// wttrSlotAt picks the hourly slot covering the observation time, since
+// wttr.in reports gusts only per slot.
+func wttrSlotAt(hourly []wttrInHour, obs time.Time) (wttrInHour, bool) {
+	var slot wttrInHour
+	found := false
+	for _, h := range hourly {
+		if int(parseFloat(h.Time)) > obs.Hour()*100+obs.Minute() {
+			break
+		}
+		slot, found = h, true
+	}
+	return slot, found
+}
+
+// wttrSlotStart converts a slot's time ("0", "300", … "2100") to an offset
+// from midnight.
+func wttrSlotStart(hhmm string) time.Duration {
+	n := int(parseFloat(hhmm))
+	return time.Duration(n/100)*time.Hour + time.Duration(n%100)*time.Minute
+}
+
+// wttrDayDetails summarizes a day from its 3-hour slots: the highest chance
+// of rain or snow and wind, total precipitation and mean humidity.
+func wttrDayDetails(hourly []wttrInHour, astro []wttrInAstronomy, uv float64) *DayDetails {
+	d := &DayDetails{UVIndex: uv}
+	humidity := 0
+	for _, h := range hourly {
+		d.ChanceOfRain = max(d.ChanceOfRain, int(parseFloat(h.ChanceOfRain)))
+		d.ChanceOfSnow = max(d.ChanceOfSnow, int(parseFloat(h.ChanceOfSnow)))
+		d.PrecipMM += parseFloat(h.PrecipMM)
+		d.PrecipIn += parseFloat(h.PrecipInches)
+		d.MaxWindKph = max(d.MaxWindKph, parseFloat(h.WindspeedKmph))
+		d.MaxWindMph = max(d.MaxWindMph, parseFloat(h.WindspeedMiles))
+		humidity += int(parseFloat(h.Humidity))
+	}
+	if len(hourly) > 0 {
+		d.AvgHumidity = humidity / len(hourly)
+	}
+	d.PrecipMM = round1(d.PrecipMM)
+	d.PrecipIn = math.Round(d.PrecipIn*100) / 100
+	if len(astro) > 0 {
+		d.Sunrise = parseClock(astro[0].Sunrise)
+		d.Sunset = parseClock(astro[0].Sunset)
+		d.Moonrise = parseClock(astro[0].Moonrise)
+	}
+	return d
+}